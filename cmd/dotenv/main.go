@@ -0,0 +1,16 @@
+// Command dotenv is a command line tool for working with .env files.
+package main
+
+import (
+	"os"
+
+	"go.followtheprocess.codes/dotenv/internal/cli"
+)
+
+func main() {
+	streams := cli.Streams{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := cli.Run(os.Args[1:], streams); err != nil {
+		cli.Report(os.Stderr, err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
@@ -0,0 +1,143 @@
+package dotenv_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+	"golang.org/x/tools/txtar"
+)
+
+var update = flag.Bool("update", false, "Update the expected output of the conformance tests")
+
+// TestConformance runs the conformance corpus in testdata/conformance, see the README
+// there for the format of each case.
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "conformance", "*.txtar"))
+	test.Ok(t, err)
+	test.True(t, len(files) != 0, test.Context("no conformance cases found"))
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txtar")
+		t.Run(name, func(t *testing.T) {
+			archive, err := txtar.ParseFile(file)
+			test.Ok(t, err)
+
+			sections := make(map[string][]byte, len(archive.Files))
+			for _, section := range archive.Files {
+				sections[section.Name] = section.Data
+			}
+
+			src, ok := sections[".env"]
+			test.True(t, ok, test.Context("%s has no .env section", file))
+
+			options := []dotenv.Option{dotenv.In(dotenv.Memory(environ(t, sections["environ"])))}
+			if text, found := sections["dialect"]; found {
+				options = append(options, dialect(t, strings.TrimSpace(string(text))))
+			}
+
+			var (
+				want = "env.json"
+				got  []byte
+			)
+
+			env, err := dotenv.Parse(".env", src, options...)
+			if err != nil {
+				want = "diagnostics"
+				got = []byte(err.Error() + "\n")
+			} else {
+				got = marshal(t, env)
+			}
+
+			if *update {
+				for i, section := range archive.Files {
+					if section.Name == "env.json" || section.Name == "diagnostics" {
+						archive.Files[i] = txtar.File{Name: want, Data: got}
+					}
+				}
+
+				test.Ok(t, os.WriteFile(file, txtar.Format(archive), 0o644))
+
+				return
+			}
+
+			expected, ok := sections[want]
+			test.True(t, ok, test.Context("%s has no %s section, got:\n%s", file, want, got))
+			test.Diff(t, string(got), string(expected))
+		})
+	}
+}
+
+// environ parses the "environ" section of a conformance case, lines of KEY=value
+// setting variables in the environment before the .env file is parsed.
+func environ(tb testing.TB, text []byte) map[string]string {
+	tb.Helper()
+
+	values := make(map[string]string)
+	for line := range strings.Lines(string(text)) {
+		key, value, ok := strings.Cut(strings.TrimSuffix(line, "\n"), "=")
+		test.True(tb, ok, test.Context("bad line in environ: %q", line))
+
+		values[key] = value
+	}
+
+	return values
+}
+
+// dialect returns the [dotenv.Dialect] with the given name.
+func dialect(tb testing.TB, name string) dotenv.Dialect {
+	tb.Helper()
+
+	for d := dotenv.Strict; d <= dotenv.POSIXShell; d++ {
+		if d.String() == name {
+			return d
+		}
+	}
+
+	tb.Fatalf("unknown dialect %q", name)
+
+	return dotenv.Strict
+}
+
+// marshal returns the variables in env as a JSON object, in the order they were
+// defined with one per line.
+func marshal(tb testing.TB, env dotenv.Env) []byte {
+	tb.Helper()
+
+	if env.Len() == 0 {
+		return []byte("{}\n")
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("{\n")
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	first := true
+	for key, value := range env.All() {
+		if !first {
+			// Replace the newline the encoder wrote with a comma and newline
+			buf.Truncate(buf.Len() - 1)
+			buf.WriteString(",\n")
+		}
+
+		first = false
+
+		buf.WriteString("  ")
+		test.Ok(tb, encoder.Encode(key))
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteString(": ")
+		test.Ok(tb, encoder.Encode(value))
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
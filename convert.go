@@ -0,0 +1,679 @@
+package dotenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a file format that variables can be converted to and from, see [Marshal]
+// and [Unmarshal].
+type Format int
+
+//go:generate stringer -type Format -linecomment
+const (
+	FormatDotenv  Format = iota // dotenv
+	FormatJSON                  // json
+	FormatYAML                  // yaml
+	FormatTOML                  // toml
+	FormatDocker                // docker
+	FormatSystemd               // systemd
+)
+
+// yamlIndent is the number of spaces nested YAML mappings are indented by.
+const yamlIndent = 2
+
+// entry is a single variable, or a group of them nested under a common key by [Nest],
+// in a document being converted to another format.
+type entry struct {
+	key      string   // The key within the parent group
+	value    string   // The value of the variable, "" for a group
+	comment  string   // The inline comment documenting the variable
+	children []*entry // The entries in the group, in order
+	group    bool     // Whether this is a group rather than a variable
+}
+
+// child returns the entry in the group called key, or nil if there isn't one.
+func (e *entry) child(key string) *entry {
+	for _, child := range e.children {
+		if child.key == key {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Marshal converts env to the given format, in the order the variables were defined.
+//
+// With the [Nest] option, keys are split on a separator and lower cased into nested
+// objects (or tables in TOML), otherwise each variable is a top level key. Inline
+// comments are kept as comments in YAML, TOML and .env, JSON has no comments so they
+// are dropped.
+//
+// [FormatDocker] and [FormatSystemd] are written one variable per line like .env, with
+// comments on the line above as neither has inline comments. Docker env files can't
+// hold values spanning multiple lines, so those are an error. So are keys, read from
+// another format by [Unmarshal], that aren't valid variable names in the file written.
+//
+// The real values of secrets are written, take care where the result ends up.
+//
+//	data, err := dotenv.Marshal(env, dotenv.FormatJSON, dotenv.Nest("__"))
+func Marshal(env Env, format Format, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatDotenv:
+		return marshalDotenv(env)
+	case FormatDocker:
+		return marshalDocker(env)
+	case FormatSystemd:
+		return marshalSystemd(env)
+	default:
+		// The rest are documents with nested keys
+	}
+
+	root, err := tree(env, cfg.separator)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return marshalJSON(root)
+	case FormatYAML:
+		return marshalYAML(root)
+	case FormatTOML:
+		return marshalTOML(root), nil
+	default:
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+}
+
+// Unmarshal converts data in the given format, read from the file name, to variables.
+//
+// The data must be an object (or table in TOML) whose values are strings, numbers,
+// booleans or null, which are converted to the text they'd be written as in the format
+// with null being empty. With the [Nest] option, nested objects are flattened by joining
+// their keys with a separator and upper casing the result, otherwise they're an error.
+// Comments on the same line as a value in YAML, or the line above it, document the
+// variable as an inline comment would in a .env file.
+//
+// [FormatDotenv] data is parsed as by [Parse], and [FormatDocker] data likewise in the
+// [DockerEnvFile] dialect. [FormatSystemd] data follows the rules of a systemd
+// EnvironmentFile, see systemd.exec(5), with the comments directly above a variable
+// documenting it.
+//
+//	env, err := dotenv.Unmarshal("config.json", data, dotenv.FormatJSON, dotenv.Nest("__"))
+func Unmarshal(name string, data []byte, format Format, options ...Option) (Env, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return Env{}, err
+	}
+
+	u := &unmarshaler{
+		classifier: secret.New(cfg.secrets...),
+		separator:  cfg.separator,
+	}
+
+	switch format {
+	case FormatDotenv:
+		return resolve(name, data, cfg)
+	case FormatDocker:
+		cfg.dialect = DockerEnvFile
+		return resolve(name, data, cfg)
+	case FormatJSON:
+		err = u.json(data)
+	case FormatYAML:
+		err = u.yaml(data)
+	case FormatTOML:
+		err = u.toml(data)
+	case FormatSystemd:
+		err = u.systemd(data)
+	default:
+		return Env{}, fmt.Errorf("invalid format: %s", format)
+	}
+
+	if err != nil {
+		return Env{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return u.env, nil
+}
+
+// tree arranges the variables in env into a tree of entries, splitting their keys on
+// separator unless it's empty.
+func tree(env Env, separator string) (*entry, error) {
+	root := &entry{group: true}
+
+	for key, value := range env.All() {
+		path := []string{key}
+		if separator != "" {
+			path = strings.Split(strings.ToLower(key), separator)
+		}
+
+		if slices.Contains(path, "") {
+			return nil, fmt.Errorf("cannot nest %s, it has an empty part between separators", key)
+		}
+
+		parent := root
+		for i, part := range path[:len(path)-1] {
+			next := parent.child(part)
+			if next == nil {
+				next = &entry{key: part, group: true}
+				parent.children = append(parent.children, next)
+			}
+
+			if !next.group {
+				return nil, fmt.Errorf("cannot nest %s under %s, it already has a value", key, strings.Join(path[:i+1], "."))
+			}
+
+			parent = next
+		}
+
+		last := path[len(path)-1]
+		if parent.child(last) != nil {
+			return nil, fmt.Errorf("cannot nest %s as %s, it is already taken", key, strings.Join(path, "."))
+		}
+
+		parent.children = append(parent.children, &entry{key: last, value: value, comment: env.Comment(key)})
+	}
+
+	return root, nil
+}
+
+// marshalDotenv writes env in .env syntax, with inline comments.
+func marshalDotenv(env Env) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for key, value := range env.All() {
+		if !isKey(key) {
+			return nil, fmt.Errorf("%q is not a valid variable name for a .env file", key)
+		}
+
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(quote(value))
+
+		if comment := env.Comment(key); comment != "" {
+			buf.WriteString(" # ")
+			buf.WriteString(comment)
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isKey reports whether key is a valid variable name in a .env file, one or more
+// letters, digits, underscores and hyphens.
+//
+// This is more permissive than [isName], as it must accept anything [Parse] does.
+func isKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := range len(key) {
+		char := key[i]
+
+		isValid := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') || char == '_' || char == '-'
+		if !isValid {
+			return false
+		}
+	}
+
+	return true
+}
+
+// marshalJSON writes the entries in root as a JSON object indented by two spaces.
+func marshalJSON(root *entry) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeJSON(buf, root, ""); err != nil {
+		return nil, fmt.Errorf("could not encode JSON: %w", err)
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes the JSON for e to buf, indenting nested lines by indent.
+func writeJSON(buf *bytes.Buffer, e *entry, indent string) error {
+	if !e.group {
+		return writeJSONString(buf, e.value)
+	}
+
+	if len(e.children) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	buf.WriteString("{\n")
+
+	for i, child := range e.children {
+		buf.WriteString(indent + "  ")
+
+		if err := writeJSONString(buf, child.key); err != nil {
+			return err
+		}
+
+		buf.WriteString(": ")
+
+		if err := writeJSON(buf, child, indent+"  "); err != nil {
+			return err
+		}
+
+		if i != len(e.children)-1 {
+			buf.WriteByte(',')
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	buf.WriteString(indent + "}")
+
+	return nil
+}
+
+// writeJSONString writes s to buf as a JSON string, leaving HTML characters alone as
+// the result isn't for a browser.
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+
+	buf.Truncate(buf.Len() - 1) // The encoder adds a newline
+
+	return nil
+}
+
+// marshalYAML writes the entries in root as a YAML mapping.
+func marshalYAML(root *entry) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(yamlIndent)
+
+	if err := encoder.Encode(yamlNode(root)); err != nil {
+		return nil, fmt.Errorf("could not encode YAML: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("could not encode YAML: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// yamlNode returns the YAML node for e. Everything is tagged as a string so that
+// e.g. "8080" and "true" are quoted, and read back as strings.
+func yamlNode(e *entry) *yaml.Node {
+	if !e.group {
+		node := yamlString(e.value)
+		node.LineComment = e.comment
+
+		return node
+	}
+
+	node := yamlMapping()
+	for _, child := range e.children {
+		node.Content = append(node.Content, yamlString(child.key), yamlNode(child))
+	}
+
+	return node
+}
+
+// marshalTOML writes the entries in root as TOML, variables nested under a group are
+// written in a table named by the group's path.
+func marshalTOML(root *entry) []byte {
+	buf := &bytes.Buffer{}
+	writeTOML(buf, root, nil)
+
+	return buf.Bytes()
+}
+
+// writeTOML writes the table for the group e, at path, followed by the tables for
+// the groups nested in it.
+func writeTOML(buf *bytes.Buffer, e *entry, path []string) {
+	var variables, groups []*entry
+	for _, child := range e.children {
+		if child.group {
+			groups = append(groups, child)
+		} else {
+			variables = append(variables, child)
+		}
+	}
+
+	if len(variables) != 0 && len(path) != 0 {
+		if buf.Len() != 0 {
+			buf.WriteByte('\n')
+		}
+
+		keys := make([]string, 0, len(path))
+		for _, part := range path {
+			keys = append(keys, tomlKey(part))
+		}
+
+		fmt.Fprintf(buf, "[%s]\n", strings.Join(keys, "."))
+	}
+
+	for _, variable := range variables {
+		fmt.Fprintf(buf, "%s = %s", tomlKey(variable.key), tomlString(variable.value))
+
+		if variable.comment != "" {
+			buf.WriteString(" # ")
+			buf.WriteString(variable.comment)
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	for _, group := range groups {
+		writeTOML(buf, group, append(slices.Clone(path), group.key))
+	}
+}
+
+// tomlKey returns key as a TOML key, bare if it can be and quoted otherwise.
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+
+	for i := range len(key) {
+		char := key[i]
+
+		isBare := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') ||
+			char == '_' || char == '-'
+		if !isBare {
+			return tomlString(key)
+		}
+	}
+
+	return key
+}
+
+// tomlString returns s as a TOML basic string, escaping quotes, backslashes and control
+// characters.
+func tomlString(s string) string {
+	b := &strings.Builder{}
+	b.WriteByte('"')
+
+	for _, char := range s {
+		switch char {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if char < ' ' || char == 0x7f {
+				fmt.Fprintf(b, `\u%04X`, char)
+				continue
+			}
+
+			b.WriteRune(char) //nolint:revive // Writing to a strings.Builder never fails
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// unmarshaler builds an [Env] from a document in another format.
+type unmarshaler struct {
+	classifier secret.Classifier // Identifies secret variables by name
+	separator  string            // Joins the keys of nested objects, "" if they aren't allowed
+	env        Env               // The variables read so far
+}
+
+// define defines the variable at path in the document, with an optional comment.
+func (u *unmarshaler) define(path []string, value, comment string) {
+	key := path[0]
+	if u.separator != "" {
+		key = strings.ToUpper(strings.Join(path, u.separator))
+	}
+
+	set(&u.env, key, value)
+
+	if comment != "" {
+		document(&u.env, key, comment)
+	}
+
+	if u.classifier.Key(key) {
+		conceal(&u.env, key)
+	}
+}
+
+// flatten returns an error if the nested object at path can't be flattened.
+func (u *unmarshaler) flatten(path []string) error {
+	if u.separator == "" {
+		return fmt.Errorf("%s is an object, use the Nest option to flatten nested objects", strings.Join(path, "."))
+	}
+
+	return nil
+}
+
+// json reads a JSON object, keeping the order of its keys.
+func (u *unmarshaler) json(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	tok, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if tok != json.Delim('{') {
+		return errors.New("invalid JSON: expected an object")
+	}
+
+	if err = u.jsonObject(decoder, nil); err != nil {
+		return err
+	}
+
+	if _, err = decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid JSON: unexpected data after the object")
+	}
+
+	return nil
+}
+
+// jsonObject reads the rest of a JSON object at path, whose opening brace has been read.
+func (u *unmarshaler) jsonObject(decoder *json.Decoder, path []string) error {
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("invalid JSON: expected a key, got %v", tok)
+		}
+
+		at := append(slices.Clone(path), key)
+
+		tok, err = decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		switch value := tok.(type) {
+		case json.Delim:
+			if value == '[' {
+				return fmt.Errorf("%s is an array, only objects and single values can be converted", strings.Join(at, "."))
+			}
+
+			if err = u.flatten(at); err != nil {
+				return err
+			}
+
+			if err = u.jsonObject(decoder, at); err != nil {
+				return err
+			}
+		case string:
+			u.define(at, value, "")
+		case json.Number:
+			u.define(at, value.String(), "")
+		case bool:
+			u.define(at, strconv.FormatBool(value), "")
+		default:
+			// null
+			u.define(at, "", "")
+		}
+	}
+
+	// The closing brace
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	return nil
+}
+
+// yaml reads a YAML mapping, keeping the order of its keys and their comments.
+func (u *unmarshaler) yaml(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		// Empty document
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%d: expected a mapping", root.Line)
+	}
+
+	return u.yamlMapping(root, nil)
+}
+
+// yamlMapping reads the YAML mapping node at path.
+func (u *unmarshaler) yamlMapping(node *yaml.Node, path []string) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+
+		at := append(slices.Clone(path), key.Value)
+
+		switch value.Kind {
+		case yaml.MappingNode:
+			if err := u.flatten(at); err != nil {
+				return fmt.Errorf("%d: %w", key.Line, err)
+			}
+
+			if err := u.yamlMapping(value, at); err != nil {
+				return err
+			}
+		case yaml.ScalarNode:
+			text := value.Value
+			if value.Tag == "!!null" {
+				text = ""
+			}
+
+			u.define(at, text, yamlComment(key, value))
+		default:
+			return fmt.Errorf(
+				"%d: %s is a list, only mappings and single values can be converted",
+				key.Line,
+				strings.Join(at, "."),
+			)
+		}
+	}
+
+	return nil
+}
+
+// yamlComment returns the comment documenting the value of key: the comment on the
+// same line if there is one, otherwise the comment above the key.
+func yamlComment(key, value *yaml.Node) string {
+	comment := value.LineComment
+	if comment == "" {
+		comment = key.LineComment
+	}
+
+	if comment == "" {
+		comment = key.HeadComment
+	}
+
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, " "))
+}
+
+// toml reads a TOML document, keeping the order of its keys. The TOML library doesn't
+// provide comments, so they're lost.
+func (u *unmarshaler) toml(data []byte) error {
+	var doc map[string]any
+
+	meta, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return fmt.Errorf("invalid TOML: %w", err)
+	}
+
+	for _, key := range meta.Keys() {
+		var value any = doc
+		for _, part := range key {
+			table, ok := value.(map[string]any)
+			if !ok {
+				// Somewhere inside an array, which has already been reported
+				break
+			}
+
+			value = table[part]
+		}
+
+		switch value := value.(type) {
+		case map[string]any:
+			if err = u.flatten(key); err != nil {
+				return err
+			}
+		case []any, []map[string]any:
+			return fmt.Errorf("%s is an array, only tables and single values can be converted", key)
+		case string:
+			u.define(key, value, "")
+		case time.Time:
+			u.define(key, value.Format(time.RFC3339Nano), "")
+		default:
+			u.define(key, fmt.Sprint(value), "")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,403 @@
+package dotenv_test
+
+import (
+	"maps"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+const convertSource = `APP__NAME=demo # The name
+APP__DB__HOST=localhost
+APP__DB__PORT=5432
+DEBUG=true
+GREETING="it's \"quoted\"\nover two lines"
+`
+
+func TestMarshal(t *testing.T) {
+	env, err := dotenv.Parse("test.env", []byte(convertSource))
+	test.Ok(t, err)
+
+	tests := []struct {
+		name    string          // Name of the test case
+		want    string          // Expected output
+		options []dotenv.Option // Options to pass to Marshal
+		format  dotenv.Format   // Format to convert to
+	}{
+		{
+			name:   "dotenv",
+			format: dotenv.FormatDotenv,
+			want: `APP__NAME=demo # The name
+APP__DB__HOST=localhost
+APP__DB__PORT=5432
+DEBUG=true
+GREETING="it's \"quoted\"\nover two lines"
+`,
+		},
+		{
+			name:   "json",
+			format: dotenv.FormatJSON,
+			want: `{
+  "APP__NAME": "demo",
+  "APP__DB__HOST": "localhost",
+  "APP__DB__PORT": "5432",
+  "DEBUG": "true",
+  "GREETING": "it's \"quoted\"\nover two lines"
+}
+`,
+		},
+		{
+			name:    "json nested",
+			format:  dotenv.FormatJSON,
+			options: []dotenv.Option{dotenv.Nest("__")},
+			want: `{
+  "app": {
+    "name": "demo",
+    "db": {
+      "host": "localhost",
+      "port": "5432"
+    }
+  },
+  "debug": "true",
+  "greeting": "it's \"quoted\"\nover two lines"
+}
+`,
+		},
+		{
+			name:    "yaml nested",
+			format:  dotenv.FormatYAML,
+			options: []dotenv.Option{dotenv.Nest("__")},
+			want: `app:
+  name: demo # The name
+  db:
+    host: localhost
+    port: "5432"
+debug: "true"
+greeting: |-
+  it's "quoted"
+  over two lines
+`,
+		},
+		{
+			name:   "toml",
+			format: dotenv.FormatTOML,
+			want: `APP__NAME = "demo" # The name
+APP__DB__HOST = "localhost"
+APP__DB__PORT = "5432"
+DEBUG = "true"
+GREETING = "it's \"quoted\"\nover two lines"
+`,
+		},
+		{
+			name:    "toml nested",
+			format:  dotenv.FormatTOML,
+			options: []dotenv.Option{dotenv.Nest("__")},
+			want: `debug = "true"
+greeting = "it's \"quoted\"\nover two lines"
+
+[app]
+name = "demo" # The name
+
+[app.db]
+host = "localhost"
+port = "5432"
+`,
+		},
+		{
+			name:   "systemd",
+			format: dotenv.FormatSystemd,
+			want: `# The name
+APP__NAME=demo
+APP__DB__HOST=localhost
+APP__DB__PORT=5432
+DEBUG=true
+GREETING="it's \"quoted\"
+over two lines"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dotenv.Marshal(env, tt.format, tt.options...)
+			test.Ok(t, err)
+			test.Diff(t, string(got), tt.want)
+
+			// And back again
+			back, err := dotenv.Unmarshal("test."+tt.format.String(), got, tt.format, tt.options...)
+			test.Ok(t, err)
+
+			test.EqualFunc(t, back.Map(), env.Map(), maps.Equal)
+
+			// JSON has no comments, and they aren't read back from TOML
+			if tt.format != dotenv.FormatJSON && tt.format != dotenv.FormatTOML {
+				test.Equal(t, back.Comment("APP__NAME"), "The name", test.Context("comment was not preserved"))
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string          // Name of the test case
+		src     string          // Source to convert
+		want    string          // Expected variables, as by Env.String
+		err     string          // Expected error, "" if none
+		options []dotenv.Option // Options to pass to Unmarshal
+		format  dotenv.Format   // Format to convert from
+	}{
+		{
+			name:   "json scalars",
+			format: dotenv.FormatJSON,
+			src:    `{"PORT": 8080, "RATIO": 1.5e3, "DEBUG": false, "EMPTY": null, "NAME": "<app>"}`,
+			want:   "PORT=8080\nRATIO=1.5e3\nDEBUG=false\nEMPTY=\"\"\nNAME=\"<app>\"",
+		},
+		{
+			name:    "json nested",
+			format:  dotenv.FormatJSON,
+			options: []dotenv.Option{dotenv.Nest("_")},
+			src:     `{"app": {"db": {"host": "localhost"}}, "api_token": "abc"}`,
+			want:    "APP_DB_HOST=localhost\nAPI_TOKEN=[REDACTED]",
+		},
+		{
+			name:   "json not nested",
+			format: dotenv.FormatJSON,
+			src:    `{"app": {"db": {"host": "localhost"}}}`,
+			err:    "test: app is an object, use the Nest option to flatten nested objects",
+		},
+		{
+			name:   "json array",
+			format: dotenv.FormatJSON,
+			src:    `{"HOSTS": ["a", "b"]}`,
+			err:    "test: HOSTS is an array, only objects and single values can be converted",
+		},
+		{
+			name:   "json not an object",
+			format: dotenv.FormatJSON,
+			src:    `["a"]`,
+			err:    "test: invalid JSON: expected an object",
+		},
+		{
+			name:   "json trailing data",
+			format: dotenv.FormatJSON,
+			src:    `{"A": "1"} {}`,
+			err:    "test: invalid JSON: unexpected data after the object",
+		},
+		{
+			name:   "yaml scalars and comments",
+			format: dotenv.FormatYAML,
+			src:    "# The port\nPORT: 8080\nDEBUG: yes # Loud\nEMPTY:\nANCHOR: &a value\nALIAS: *a\n",
+			want:   "PORT=8080\nDEBUG=yes\nEMPTY=\"\"\nANCHOR=value\nALIAS=value",
+		},
+		{
+			name:   "yaml empty",
+			format: dotenv.FormatYAML,
+			src:    "",
+			want:   "",
+		},
+		{
+			name:   "yaml list",
+			format: dotenv.FormatYAML,
+			src:    "A: 1\nHOSTS:\n  - a\n",
+			err:    "test: 2: HOSTS is a list, only mappings and single values can be converted",
+		},
+		{
+			name:   "yaml not a mapping",
+			format: dotenv.FormatYAML,
+			src:    "- a\n",
+			err:    "test: 1: expected a mapping",
+		},
+		{
+			name:    "toml tables",
+			format:  dotenv.FormatTOML,
+			options: []dotenv.Option{dotenv.Nest("__")},
+			src:     "port = 8080\nwhen = 2024-01-02T03:04:05Z\n\n[db]\nhost = \"localhost\"\ninline = { a = true }\n",
+			want:    "PORT=8080\nWHEN=2024-01-02T03:04:05Z\nDB__HOST=localhost\nDB__INLINE__A=true",
+		},
+		{
+			name:   "toml array",
+			format: dotenv.FormatTOML,
+			src:    "hosts = [\"a\"]\n",
+			err:    "test: hosts is an array, only tables and single values can be converted",
+		},
+		{
+			name:   "docker",
+			format: dotenv.FormatDocker,
+			src:    "# A comment\nNAME=\"quotes\" and $VARS # are kept\nPORT=8080\n",
+			want:   "NAME=\"\\\"quotes\\\" and \\$VARS # are kept\"\nPORT=8080",
+		},
+		{
+			name:   "systemd unquoted",
+			format: dotenv.FormatSystemd,
+			src:    "; A comment\n  NAME =  hello  world  \nPATH=/bin:$PATH\nESCAPED=a\\ b\\\\c\nQUOTES=it's \"fine\"\nJOINED=a\\\n  b\nEMPTY=\nignored line\n",
+			want:   "NAME=\"hello  world\"\nPATH=\"/bin:\\$PATH\"\nESCAPED=\"a b\\\\c\"\nQUOTES=\"it's \\\"fine\\\"\"\nJOINED=\"a  b\"\nEMPTY=\"\"",
+		},
+		{
+			name:   "systemd quoted",
+			format: dotenv.FormatSystemd,
+			src:    "SINGLE='a \\n\nb'\nDOUBLE=\"say \\\"hi\\\" \\n \\\nthere\"\nPARTS='a' \"b\"c\n",
+			want:   "SINGLE=\"a \\\\n\\nb\"\nDOUBLE=\"say \\\"hi\\\" \\\\n there\"\nPARTS=abc",
+		},
+		{
+			name:   "systemd unterminated",
+			format: dotenv.FormatSystemd,
+			src:    "A=1\nB=\"open\n",
+			err:    "test: line 2: B: unterminated double quoted value",
+		},
+		{
+			name:   "systemd bad name",
+			format: dotenv.FormatSystemd,
+			src:    "A='multi\nline'\nexport B=2\n",
+			err:    `test: line 3: "export B" is not a valid variable name`,
+		},
+		{
+			name:   "invalid format",
+			format: dotenv.Format(42),
+			err:    "invalid format: Format(42)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Unmarshal("test", []byte(tt.src), tt.format, tt.options...)
+			if tt.err != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.err)
+
+				return
+			}
+
+			test.Ok(t, err)
+			test.Equal(t, env.String(), tt.want)
+		})
+	}
+}
+
+func TestUnmarshalComments(t *testing.T) {
+	env, err := dotenv.Unmarshal("test", []byte("# The port\n# to listen on\nPORT: 8080\nDEBUG: yes # Loud\n"), dotenv.FormatYAML)
+	test.Ok(t, err)
+
+	test.Equal(t, env.Comment("PORT"), "The port to listen on")
+	test.Equal(t, env.Comment("DEBUG"), "Loud")
+}
+
+func TestMarshalDocker(t *testing.T) {
+	env, err := dotenv.Parse("test.env", []byte("NAME=\"it's $$ and # \" # The name\nPORT=8080\n"))
+	test.Ok(t, err)
+
+	got, err := dotenv.Marshal(env, dotenv.FormatDocker)
+	test.Ok(t, err)
+	test.Diff(t, string(got), "# The name\nNAME=it's $$ and # \nPORT=8080\n")
+
+	back, err := dotenv.Unmarshal("test.docker", got, dotenv.FormatDocker)
+	test.Ok(t, err)
+	test.EqualFunc(t, back.Map(), env.Map(), maps.Equal)
+
+	env, err = dotenv.Parse("test.env", []byte(convertSource))
+	test.Ok(t, err)
+
+	_, err = dotenv.Marshal(env, dotenv.FormatDocker)
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "the value of GREETING spans multiple lines, which docker env files can't represent")
+}
+
+func TestMarshalInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case
+		json string // JSON object to convert
+		err  string // Expected error
+	}{
+		{
+			name: "space",
+			json: `{"my key": "v"}`,
+			err:  `"my key" is not a valid variable name for a .env file`,
+		},
+		{
+			name: "empty",
+			json: `{"": "empty"}`,
+			err:  `"" is not a valid variable name for a .env file`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Unmarshal("test.json", []byte(tt.json), dotenv.FormatJSON)
+			test.Ok(t, err)
+
+			_, err = dotenv.Marshal(env, dotenv.FormatDotenv)
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.err)
+		})
+	}
+
+	// Anything Parse accepts is fine
+	env, err := dotenv.Unmarshal("test.json", []byte(`{"app-name": "v", "_1": "w"}`), dotenv.FormatJSON)
+	test.Ok(t, err)
+
+	got, err := dotenv.Marshal(env, dotenv.FormatDotenv)
+	test.Ok(t, err)
+
+	back, err := dotenv.Parse("test.env", got)
+	test.Ok(t, err)
+	test.EqualFunc(t, back.Map(), env.Map(), maps.Equal)
+}
+
+func TestUnmarshalSystemdSecrets(t *testing.T) {
+	src := "# The database @secret\nDSN=postgres://db\nAPI_TOKEN=abc\n\n# Not a secret\nPORT=8080\n"
+
+	env, err := dotenv.Unmarshal("test", []byte(src), dotenv.FormatSystemd)
+	test.Ok(t, err)
+
+	test.True(t, env.IsSecret("DSN"))
+	test.True(t, env.IsSecret("API_TOKEN"))
+	test.False(t, env.IsSecret("PORT"))
+	test.Equal(t, env.Comment("DSN"), "The database @secret")
+	test.Equal(t, env.Comment("API_TOKEN"), "")
+}
+
+func TestMarshalNestErrors(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case
+		src  string // Source of the variables to convert
+		err  string // Expected error
+	}{
+		{
+			name: "value then nested",
+			src:  "APP=1\nAPP__DB=2\n",
+			err:  "cannot nest APP__DB under app, it already has a value",
+		},
+		{
+			name: "nested then value",
+			src:  "APP__DB=2\nAPP=1\n",
+			err:  "cannot nest APP as app, it is already taken",
+		},
+		{
+			name: "case collision",
+			src:  "APP=1\napp=2\n",
+			err:  "cannot nest app as app, it is already taken",
+		},
+		{
+			name: "empty part",
+			src:  "APP____DB=1\n",
+			err:  "cannot nest APP____DB, it has an empty part between separators",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Parse("test.env", []byte(tt.src))
+			test.Ok(t, err)
+
+			_, err = dotenv.Marshal(env, dotenv.FormatJSON, dotenv.Nest("__"))
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := dotenv.Marshal(dotenv.Env{}, dotenv.Format(42))
+	test.Err(t, err)
+
+	_, err = dotenv.Marshal(dotenv.Env{}, dotenv.FormatJSON, dotenv.Nest(""))
+	test.Err(t, err)
+}
@@ -0,0 +1,449 @@
+package dotenv
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+)
+
+const (
+	// KeyVar is the environment variable [KeyFromEnv] reads an encryption key from.
+	KeyVar = "DOTENV_KEY"
+
+	// KeyFileVar is the environment variable [KeyFromEnv] reads the path to a file
+	// containing an encryption key from.
+	KeyFileVar = "DOTENV_KEY_FILE"
+)
+
+const (
+	aesPrefix = "ENC[AES256_GCM," // Prefix of a value encrypted with a symmetric key
+	agePrefix = "ENC[AGE,"        // Prefix of a value encrypted with age
+	aesKeyLen = 32                // Length in bytes of an AES-256 key
+)
+
+// Decrypter decrypts the encrypted values in a .env file.
+//
+// The name is the name of the variable the value is assigned to and value is the
+// encrypted value in full e.g. "ENC[AES256_GCM,data:...,iv:...,tag:...]".
+type Decrypter interface {
+	// Decrypt decrypts the encrypted value of the variable name.
+	Decrypt(name, value string) (string, error)
+}
+
+// Encrypter encrypts values for storing in a .env file, it is the inverse of a [Decrypter].
+type Encrypter interface {
+	// Encrypt encrypts value, the value of the variable name.
+	Encrypt(name, value string) (string, error)
+}
+
+// Key is a key for encrypting and decrypting the values in a .env file, it implements
+// both [Encrypter] and [Decrypter].
+//
+// A Key is one of:
+//
+//   - A symmetric AES-256 key, values are encrypted with AES-GCM as ENC[AES256_GCM,data:...,iv:...,tag:...]
+//   - An age X25519 identity (AGE-SECRET-KEY-1...), values are encrypted with age as ENC[AGE,data:...]
+//   - One or more age recipients (age1...), which can only encrypt
+//
+// Either way the name of the variable is bound to its encrypted value, so it cannot be
+// moved to another variable without failing to decrypt.
+type Key struct {
+	aead       cipher.AEAD     // The AES-GCM cipher for a symmetric key
+	identities []age.Identity  // age identities, for decryption
+	recipients []age.Recipient // age recipients, for encryption
+}
+
+// GenerateKey generates a new random symmetric key, returning its text form
+// for use with [ParseKey].
+func GenerateKey() (string, error) {
+	key := make([]byte, aesKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("could not generate key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseKey parses the text form of a [Key].
+//
+// The text is either a base64 encoded 32 byte symmetric key as generated by [GenerateKey],
+// the contents of an age identity file, or one or more age recipients, one per line.
+func ParseKey(text []byte) (Key, error) {
+	text = bytes.TrimSpace(text)
+
+	switch {
+	case len(text) == 0:
+		return Key{}, errors.New("empty encryption key")
+	case bytes.Contains(text, []byte("AGE-SECRET-KEY-")):
+		identities, err := age.ParseIdentities(bytes.NewReader(text))
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid age identity: %w", err)
+		}
+
+		var recipients []age.Recipient
+		for _, identity := range identities {
+			if x25519, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x25519.Recipient())
+			}
+		}
+
+		return Key{identities: identities, recipients: recipients}, nil
+	case bytes.HasPrefix(text, []byte("age1")):
+		recipients, err := age.ParseRecipients(bytes.NewReader(text))
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid age recipient: %w", err)
+		}
+
+		return Key{recipients: recipients}, nil
+	default:
+		raw, err := base64.StdEncoding.DecodeString(string(text))
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid encryption key, expected base64 or an age key: %w", err)
+		}
+
+		if len(raw) != aesKeyLen {
+			return Key{}, fmt.Errorf("invalid encryption key, expected %d bytes, got %d", aesKeyLen, len(raw))
+		}
+
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return Key{}, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return Key{aead: aead}, nil
+	}
+}
+
+// ReadKey reads and parses the [Key] in the file at path.
+func ReadKey(path string) (Key, error) {
+	text, err := os.ReadFile(path) //nolint:gosec // Reading the user's key file is the point
+	if err != nil {
+		return Key{}, err
+	}
+
+	key, err := ParseKey(text)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// KeyFromEnv returns the [Key] given by the environment, either the text of a
+// key in $DOTENV_KEY or the path to a file containing one in $DOTENV_KEY_FILE.
+func KeyFromEnv() (Key, error) {
+	if text := os.Getenv(KeyVar); text != "" {
+		key, err := ParseKey([]byte(text))
+		if err != nil {
+			return Key{}, fmt.Errorf("$%s: %w", KeyVar, err)
+		}
+
+		return key, nil
+	}
+
+	if path := os.Getenv(KeyFileVar); path != "" {
+		return ReadKey(path)
+	}
+
+	return Key{}, fmt.Errorf("no encryption key, set $%s or $%s", KeyVar, KeyFileVar)
+}
+
+// IsEncrypted reports whether value is an encrypted value e.g. "ENC[AES256_GCM,...]".
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, "ENC[") && strings.HasSuffix(value, "]")
+}
+
+// Encrypt implements [Encrypter] for a [Key].
+func (k Key) Encrypt(name, value string) (string, error) {
+	switch {
+	case k.aead != nil:
+		iv := make([]byte, k.aead.NonceSize())
+		if _, err := rand.Read(iv); err != nil {
+			return "", fmt.Errorf("could not encrypt %s: %w", name, err)
+		}
+
+		sealed := k.aead.Seal(nil, iv, []byte(value), []byte(name)) //nolint:gosec // The iv is random, see above
+		data, tag := sealed[:len(sealed)-k.aead.Overhead()], sealed[len(sealed)-k.aead.Overhead():]
+
+		return fmt.Sprintf("%sdata:%s,iv:%s,tag:%s]", aesPrefix, encode(data), encode(iv), encode(tag)), nil
+	case len(k.recipients) != 0:
+		out := &bytes.Buffer{}
+
+		w, err := age.Encrypt(out, k.recipients...)
+		if err != nil {
+			return "", fmt.Errorf("could not encrypt %s: %w", name, err)
+		}
+
+		if _, err := io.WriteString(w, name+"\x00"+value); err != nil {
+			return "", fmt.Errorf("could not encrypt %s: %w", name, err)
+		}
+
+		if err := w.Close(); err != nil {
+			return "", fmt.Errorf("could not encrypt %s: %w", name, err)
+		}
+
+		return fmt.Sprintf("%sdata:%s]", agePrefix, encode(out.Bytes())), nil
+	default:
+		return "", errors.New("cannot encrypt with an empty key")
+	}
+}
+
+// Decrypt implements [Decrypter] for a [Key].
+func (k Key) Decrypt(name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("value of %s is not encrypted", name)
+	}
+
+	switch {
+	case strings.HasPrefix(value, aesPrefix):
+		if k.aead == nil {
+			return "", fmt.Errorf("cannot decrypt %s, it was encrypted with a symmetric key", name)
+		}
+
+		fields, err := encryptedFields(value, aesPrefix, "data", "iv", "tag")
+		if err != nil {
+			return "", fmt.Errorf("bad encrypted value for %s: %w", name, err)
+		}
+
+		data, iv, tag := fields[0], fields[1], fields[2]
+		if len(iv) != k.aead.NonceSize() || len(tag) != k.aead.Overhead() {
+			return "", fmt.Errorf("bad encrypted value for %s: wrong iv or tag length", name)
+		}
+
+		plaintext, err := k.aead.Open(nil, iv, slices.Concat(data, tag), []byte(name))
+		if err != nil {
+			return "", fmt.Errorf("could not decrypt %s, wrong key or tampered value", name)
+		}
+
+		return string(plaintext), nil
+	case strings.HasPrefix(value, agePrefix):
+		if len(k.identities) == 0 {
+			return "", fmt.Errorf("cannot decrypt %s, it was encrypted with age and the key is not an age identity", name)
+		}
+
+		fields, err := encryptedFields(value, agePrefix, "data")
+		if err != nil {
+			return "", fmt.Errorf("bad encrypted value for %s: %w", name, err)
+		}
+
+		r, err := age.Decrypt(bytes.NewReader(fields[0]), k.identities...)
+		if err != nil {
+			return "", fmt.Errorf("could not decrypt %s: %w", name, err)
+		}
+
+		plaintext, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("could not decrypt %s: %w", name, err)
+		}
+
+		bound, decrypted, ok := strings.Cut(string(plaintext), "\x00")
+		if !ok || bound != name {
+			return "", fmt.Errorf("could not decrypt %s, the value belongs to another variable", name)
+		}
+
+		return decrypted, nil
+	default:
+		return "", fmt.Errorf("cannot decrypt %s, unsupported encryption %q", name, value[:strings.IndexAny(value, ",]")+1])
+	}
+}
+
+// Decrypt is an [Option] that decrypts encrypted values e.g. "ENC[AES256_GCM,...]" using d
+// as they are loaded.
+//
+// A value is encrypted if it is of the form ENC[...] once any quotes are removed, the
+// decrypted value is used exactly as is with no interpolation or escape sequences.
+// Without this option, encrypted values are left untouched.
+//
+//	key, err := dotenv.KeyFromEnv()
+//	env, err := dotenv.Read(".env.production", dotenv.Decrypt(key))
+func Decrypt(d Decrypter) Option {
+	f := func(cfg *config) error {
+		if d == nil {
+			return errors.New("cannot decrypt with a nil Decrypter")
+		}
+
+		cfg.decrypter = d
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// EncryptFile encrypts every value in the .env source src using enc, returning the
+// new source.
+//
+// Only the values are changed, keys, comments and formatting are left exactly as they
+// were so the file can still be reviewed. Values are encrypted as written, with quotes
+// removed and escape sequences decoded, but nothing is expanded. Values that use
+// interpolation or command substitution are left alone, as are empty and already
+// encrypted values.
+func EncryptFile(name string, src []byte, enc Encrypter, options ...Option) ([]byte, error) {
+	if enc == nil {
+		return nil, errors.New("cannot encrypt with a nil Encrypter")
+	}
+
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.literal = true
+
+	ev, err := newEvaluator(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return rewrite(src, ev.file, func(assignment *ast.Assignment) (string, bool, error) {
+		value, literal, err := ev.literalValue(assignment)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !literal || value == "" || IsEncrypted(value) {
+			return "", false, nil
+		}
+
+		encrypted, err := enc.Encrypt(assignment.Key.Text, value)
+		if err != nil {
+			return "", false, err
+		}
+
+		return encrypted, true, nil
+	})
+}
+
+// DecryptFile decrypts every encrypted value in the .env source src using dec,
+// returning the new source.
+//
+// Only the encrypted values are changed, they are replaced by their plaintext, quoted
+// as necessary for the file's [Dialect]. Everything else is left exactly as it was,
+// nothing is expanded and no commands are run.
+func DecryptFile(name string, src []byte, dec Decrypter, options ...Option) ([]byte, error) {
+	if dec == nil {
+		return nil, errors.New("cannot decrypt with a nil Decrypter")
+	}
+
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.decrypter = dec
+	cfg.literal = true
+
+	ev, err := newEvaluator(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return rewrite(src, ev.file, func(assignment *ast.Assignment) (string, bool, error) {
+		value, literal, err := ev.literalValue(assignment)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !literal || !IsEncrypted(value) {
+			return "", false, nil
+		}
+
+		decrypted, err := ev.decrypt(assignment, value)
+		if err != nil {
+			return "", false, err
+		}
+
+		quoted, err := quoteFor(cfg.dialect, assignment.Key.Text, decrypted)
+		if err != nil {
+			return "", false, err
+		}
+
+		return quoted, true, nil
+	})
+}
+
+// rewrite returns a copy of src with the values of the assignments in file replaced
+// by the result of replace, where it reports true.
+func rewrite(src []byte, file ast.File, replace func(assignment *ast.Assignment) (string, bool, error)) ([]byte, error) {
+	out := &bytes.Buffer{}
+	out.Grow(len(src))
+
+	last := 0
+	for _, assignment := range file.Assignments() {
+		if len(assignment.Value) == 0 {
+			continue
+		}
+
+		replacement, ok, err := replace(assignment)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		start, end := assignment.ValuePos(), assignment.End()
+		out.Write(src[last:start])
+		out.WriteString(replacement)
+		last = end
+	}
+
+	out.Write(src[last:])
+
+	return out.Bytes(), nil
+}
+
+// encryptedFields extracts the named, base64 encoded fields from an encrypted
+// value e.g. "ENC[AES256_GCM,data:...,iv:...,tag:...]", in the order given.
+func encryptedFields(value, prefix string, names ...string) ([][]byte, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(value, prefix), "]")
+
+	found := make(map[string]string)
+	for field := range strings.SplitSeq(body, ",") {
+		name, data, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed field %q", field)
+		}
+
+		found[name] = data
+	}
+
+	fields := make([][]byte, 0, len(names))
+	for _, name := range names {
+		data, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("missing %s", name)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s: %w", name, err)
+		}
+
+		fields = append(fields, decoded)
+	}
+
+	return fields, nil
+}
+
+// encode base64 encodes data.
+func encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
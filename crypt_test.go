@@ -0,0 +1,300 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	symmetric, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	identity, err := age.GenerateX25519Identity()
+	test.Ok(t, err)
+
+	tests := []struct {
+		name       string // Name of the test case
+		key        string // Text of the key to encrypt and decrypt with
+		wantPrefix string // Expected prefix of encrypted values
+	}{
+		{name: "symmetric", key: symmetric, wantPrefix: "ENC[AES256_GCM,data:"},
+		{name: "age", key: identity.String(), wantPrefix: "ENC[AGE,data:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := dotenv.ParseKey([]byte(tt.key))
+			test.Ok(t, err)
+
+			values := []string{"hunter2", "", `with "quotes" and $dollars`, "multiple\nlines"}
+			for _, value := range values {
+				encrypted, err := key.Encrypt("PASSWORD", value)
+				test.Ok(t, err)
+
+				test.True(t, strings.HasPrefix(encrypted, tt.wantPrefix), test.Context("encrypted value %q", encrypted))
+				test.True(t, dotenv.IsEncrypted(encrypted))
+
+				decrypted, err := key.Decrypt("PASSWORD", encrypted)
+				test.Ok(t, err)
+				test.Equal(t, decrypted, value)
+
+				_, err = key.Decrypt("OTHER", encrypted)
+				test.Err(t, err, test.Context("value should be bound to its variable name"))
+			}
+		})
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	text, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	key, err := dotenv.ParseKey([]byte(text))
+	test.Ok(t, err)
+
+	otherText, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	other, err := dotenv.ParseKey([]byte(otherText))
+	test.Ok(t, err)
+
+	encrypted, err := key.Encrypt("A", "secret")
+	test.Ok(t, err)
+
+	_, err = other.Decrypt("A", encrypted)
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "could not decrypt A, wrong key or tampered value")
+
+	identity, err := age.GenerateX25519Identity()
+	test.Ok(t, err)
+
+	recipient, err := dotenv.ParseKey([]byte(identity.Recipient().String()))
+	test.Ok(t, err)
+
+	encrypted, err = recipient.Encrypt("A", "secret")
+	test.Ok(t, err)
+
+	_, err = recipient.Decrypt("A", encrypted)
+	test.Err(t, err, test.Context("an age recipient cannot decrypt"))
+
+	_, err = key.Decrypt("A", "ENC[ROT13,data:abc]")
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `cannot decrypt A, unsupported encryption "ENC[ROT13,"`)
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	tests := []struct {
+		name    string // Name of the test case
+		text    string // Key text
+		wantErr string // Expected error
+	}{
+		{name: "empty", text: "  \n", wantErr: "empty encryption key"},
+		{name: "short", text: "aGVsbG8=", wantErr: "invalid encryption key, expected 32 bytes, got 5"},
+		{
+			name:    "not base64",
+			text:    "not a key!",
+			wantErr: "invalid encryption key, expected base64 or an age key: illegal base64 data at input byte 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dotenv.ParseKey([]byte(tt.text))
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	text, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	t.Run("none", func(t *testing.T) {
+		t.Setenv(dotenv.KeyVar, "")
+		t.Setenv(dotenv.KeyFileVar, "")
+
+		_, err := dotenv.KeyFromEnv()
+		test.Err(t, err)
+	})
+
+	t.Run("var", func(t *testing.T) {
+		t.Setenv(dotenv.KeyVar, text)
+
+		_, err := dotenv.KeyFromEnv()
+		test.Ok(t, err)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key")
+		test.Ok(t, os.WriteFile(path, []byte(text+"\n"), 0o600))
+
+		t.Setenv(dotenv.KeyVar, "")
+		t.Setenv(dotenv.KeyFileVar, path)
+
+		_, err := dotenv.KeyFromEnv()
+		test.Ok(t, err)
+	})
+}
+
+func TestEncryptFile(t *testing.T) {
+	text, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	key, err := dotenv.ParseKey([]byte(text))
+	test.Ok(t, err)
+
+	src := `# Database settings
+DB_USER=admin # Who to connect as
+DB_PASSWORD="p@ss \"word\" $$"
+EMPTY=
+URL=postgres://${DB_USER}@localhost
+export TOKEN='abc'
+`
+
+	encrypted, err := dotenv.EncryptFile("prod.env", []byte(src), key)
+	test.Ok(t, err)
+
+	lines := strings.Split(string(encrypted), "\n")
+	test.Equal(t, lines[0], "# Database settings", test.Context("comments should be untouched"))
+	test.True(t, strings.HasPrefix(lines[1], "DB_USER=ENC[AES256_GCM,"), test.Context("line: %q", lines[1]))
+	test.True(t, strings.HasSuffix(lines[1], "] # Who to connect as"), test.Context("inline comments should be kept"))
+	test.Equal(t, lines[3], "EMPTY=", test.Context("empty values are not encrypted"))
+	test.Equal(t, lines[4], "URL=postgres://${DB_USER}@localhost", test.Context("interpolated values are not encrypted"))
+	test.True(t, strings.HasPrefix(lines[5], "export TOKEN=ENC["), test.Context("line: %q", lines[5]))
+
+	// Commands are neither run nor encrypted
+	commands := "NOW=$(exit 1)\n"
+	got, err := dotenv.EncryptFile("commands.env", []byte(commands), key)
+	test.Ok(t, err)
+	test.Equal(t, string(got), commands)
+
+	// Encrypting again should change nothing
+	again, err := dotenv.EncryptFile("prod.env", encrypted, key)
+	test.Ok(t, err)
+	test.Equal(t, string(again), string(encrypted))
+
+	// Without a decrypter, encrypted values are left alone
+	env, err := dotenv.Parse("prod.env", encrypted)
+	test.Ok(t, err)
+
+	password, _ := env.Get("DB_PASSWORD")
+	test.True(t, dotenv.IsEncrypted(password))
+
+	env, err = dotenv.Parse("prod.env", encrypted, dotenv.Decrypt(key))
+	test.Ok(t, err)
+
+	want := map[string]string{
+		"DB_USER":     "admin",
+		"DB_PASSWORD": `p@ss "word" $$`,
+		"EMPTY":       "",
+		"URL":         "postgres://admin@localhost",
+		"TOKEN":       "abc",
+	}
+
+	for name, value := range want {
+		got, ok := env.Get(name)
+		test.True(t, ok, test.Context("%s missing", name))
+		test.Equal(t, got, value, test.Context("wrong value for %s", name))
+	}
+
+	decrypted, err := dotenv.DecryptFile("prod.env", encrypted, key)
+	test.Ok(t, err)
+
+	wantSrc := `# Database settings
+DB_USER=admin # Who to connect as
+DB_PASSWORD="p@ss \"word\" \$\$"
+EMPTY=
+URL=postgres://${DB_USER}@localhost
+export TOKEN=abc
+`
+	test.Diff(t, string(decrypted), wantSrc)
+
+	// Decrypting neither runs commands nor needs the variables referenced to be defined
+	marker := filepath.Join(t.TempDir(), "ran")
+	others := "\nB=${UNDEFINED}\nC=$(touch " + marker + ")\n"
+
+	decrypted, err = dotenv.DecryptFile("prod.env", append(encrypted, others...), key)
+	test.Ok(t, err)
+	test.Diff(t, string(decrypted), wantSrc+others)
+
+	_, err = os.Stat(marker)
+	test.True(t, os.IsNotExist(err), test.Context("command was run"))
+}
+
+func TestEncryptFileDialects(t *testing.T) {
+	text, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	key, err := dotenv.ParseKey([]byte(text))
+	test.Ok(t, err)
+
+	tests := []struct {
+		name    string         // Name of the test case
+		src     string         // Source to encrypt then decrypt
+		want    string         // Expected source after decrypting
+		dialect dotenv.Dialect // Dialect of the source
+	}{
+		{
+			name:    "strict",
+			src:     "A=\"it's \\\"here\\\"\"\n",
+			want:    "A=\"it's \\\"here\\\"\"\n",
+			dialect: dotenv.Strict,
+		},
+		{
+			name:    "posix",
+			src:     "A=it\\'s\\ \\$here\n",
+			want:    "A='it'\\''s $here'\n",
+			dialect: dotenv.POSIXShell,
+		},
+		{
+			name:    "python",
+			src:     `A='it\'s ${here}'` + "\n",
+			want:    `A='it\'s ${here}'` + "\n",
+			dialect: dotenv.PythonDotenv,
+		},
+		{
+			name:    "node",
+			src:     "A=`it's \\n`\n",
+			want:    "A=`it's \\n`\n",
+			dialect: dotenv.NodeDotenv,
+		},
+		{
+			name:    "docker",
+			src:     "A=\"it's\" $here\n",
+			want:    "A=\"it's\" $here\n",
+			dialect: dotenv.DockerEnvFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := dotenv.EncryptFile(tt.name, []byte(tt.src), key, tt.dialect)
+			test.Ok(t, err)
+
+			test.True(t, strings.HasPrefix(string(encrypted), "A=ENC["), test.Context("A not encrypted: %q", encrypted))
+
+			decrypted, err := dotenv.DecryptFile(tt.name, encrypted, key, tt.dialect)
+			test.Ok(t, err)
+			test.Diff(t, string(decrypted), tt.want)
+		})
+	}
+}
+
+func TestDecryptError(t *testing.T) {
+	text, err := dotenv.GenerateKey()
+	test.Ok(t, err)
+
+	key, err := dotenv.ParseKey([]byte(text))
+	test.Ok(t, err)
+
+	_, err = dotenv.Parse("bad.env", []byte("A=1\nB=ENC[AES256_GCM,data:AAAA,iv:AAAA,tag:AAAA]\n"), dotenv.Decrypt(key))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "bad.env:2:3-45: bad encrypted value for B: wrong iv or tag length")
+}
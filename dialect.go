@@ -0,0 +1,80 @@
+package dotenv
+
+import "go.followtheprocess.codes/dotenv/internal/syntax"
+
+// Dialect is a flavour of .env syntax.
+//
+// Different tools disagree on the finer points of .env files, such as how quotes,
+// comments and interpolation work. A Dialect is an [Option] selecting which tool's
+// rules to follow, so that a file means the same thing when loaded by dotenv as it
+// does everywhere else it's used.
+//
+//	env, err := dotenv.Read(".env", dotenv.Compose)
+type Dialect int
+
+const (
+	// Strict is dotenv's own dialect and the default.
+	//
+	//   - Unquoted values end at whitespace, '#' preceded by whitespace begins a comment
+	//   - A backslash at the end of a line continues an unquoted value onto the next line
+	//   - $VAR, ${VAR} and $(command) are expanded in unquoted and double quoted values
+	//   - Referencing an undefined variable is an error, unless a default is given e.g. ${VAR:-default}
+	//   - Double quoted values support escape sequences including \", single quoted values
+	//     are literal except that a single quote is written by doubling it
+	//   - """ begins a multiline string and three single quotes a literal one, the contents
+	//     of both are dedented (see the README for the details)
+	Strict = Dialect(syntax.Strict)
+
+	// Compose follows the rules of docker compose's .env files.
+	//
+	//   - Unquoted values run to the end of the line, '#' preceded by whitespace begins a comment
+	//   - $VAR and ${VAR} are expanded in unquoted and double quoted values, "$$" is a literal '$'
+	//   - Undefined variables expand to the empty string
+	//   - There is no command substitution
+	//   - Double quoted values support escape sequences, single quoted values are literal
+	Compose = Dialect(syntax.Compose)
+
+	// DockerEnvFile follows the rules of 'docker run --env-file'.
+	//
+	//   - Everything after the '=' is the value, taken literally with no quote handling,
+	//     expansion or inline comments
+	//   - A line with just a variable name takes its value from the environment, and is
+	//     omitted if it is not set
+	//   - The export keyword is not allowed
+	DockerEnvFile = Dialect(syntax.DockerEnvFile)
+
+	// PythonDotenv follows the rules of the python-dotenv package.
+	//
+	//   - Unquoted values run to the end of the line, '#' preceded by whitespace begins a comment
+	//   - Only ${VAR} is expanded (not $VAR), in unquoted and double quoted values
+	//   - Undefined variables expand to the empty string
+	//   - There is no command substitution
+	//   - Double quoted values support Python's escape sequences, single quoted values are
+	//     literal except for the escapes \' and \\
+	PythonDotenv = Dialect(syntax.PythonDotenv)
+
+	// NodeDotenv follows the rules of the Node.js dotenv package.
+	//
+	//   - Unquoted values run to the end of the line, any '#' begins a comment
+	//   - There is no expansion or command substitution
+	//   - Values may be quoted with ', " or `, in double quoted values only \n and \r
+	//     are decoded
+	NodeDotenv = Dialect(syntax.NodeDotenv)
+
+	// POSIXShell follows the rules of sourcing the file in a POSIX shell.
+	//
+	//   - Unquoted values end at whitespace and a backslash escapes the next character,
+	//     '#' preceded by whitespace begins a comment
+	//   - A backslash at the end of a line continues an unquoted value onto the next line
+	//   - $VAR, ${VAR} and $(command) are expanded in unquoted and double quoted values
+	//   - Undefined variables expand to the empty string
+	//   - In double quoted values, only \$, \`, \" and \\ are escape sequences
+	//   - Single quoted values are literal and cannot contain a single quote, instead end
+	//     the quotes, add an escaped quote and start them again like in a shell
+	POSIXShell = Dialect(syntax.POSIXShell)
+)
+
+// String implements [fmt.Stringer] for a [Dialect].
+func (d Dialect) String() string {
+	return syntax.Dialect(d).String()
+}
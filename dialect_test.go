@@ -0,0 +1,461 @@
+package dotenv_test
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/internal/syntax/corpus"
+	"go.followtheprocess.codes/test"
+)
+
+// hashInValue matches a '#' that isn't at the start of a line or after whitespace, which
+// node's dotenv takes as the start of a comment and the other dialects as part of a value.
+var hashInValue = regexp.MustCompile(`[^ \t\r\n]#`)
+
+// conformance is a single case in a dialect's conformance suite.
+type conformance struct {
+	want    map[string]string // Expected resolved variables
+	name    string            // Name of the test case
+	src     string            // Source of the .env file
+	wantErr string            // Expected error message, if any
+}
+
+// runConformance runs a conformance suite for a dialect.
+func runConformance(t *testing.T, dialect dotenv.Dialect, tests []conformance) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Parse(tt.name, []byte(tt.src), dialect)
+			if tt.wantErr != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			test.Ok(t, err)
+			test.EqualFunc(t, env.Map(), tt.want, maps.Equal, test.Context("%s resolved env mismatch", dialect))
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.Strict, []conformance{
+		{
+			name: "bare",
+			src:  "A=1\nB=two",
+			want: map[string]string{"A": "1", "B": "two"},
+		},
+		{
+			name: "export",
+			src:  "export A=1",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "inline comment",
+			src:  "A=1 # A comment",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "interpolation",
+			src:  "A=1\nB=$A\nC=${A}\nD=${DOTENV_TEST_USER}@email.com",
+			want: map[string]string{"A": "1", "B": "1", "C": "1", "D": "me@email.com"},
+		},
+		{
+			name: "double quoted",
+			src:  `A=there` + "\n" + `B="hello ${A}\tand \$A"`,
+			want: map[string]string{"A": "there", "B": "hello there\tand $A"},
+		},
+		{
+			name: "single quoted",
+			src:  `A='${DOTENV_TEST_USER} \n $(whoami)'`,
+			want: map[string]string{"A": `${DOTENV_TEST_USER} \n $(whoami)`},
+		},
+		{
+			name: "command substitution",
+			src:  `A=$(echo hello)` + "\n" + `B="$(echo $A) there"`,
+			want: map[string]string{"A": "hello", "B": "hello there"},
+		},
+		{
+			name: "default",
+			src:  "A=${DOTENV_TEST_UNSET:-default}",
+			want: map[string]string{"A": "default"},
+		},
+		{
+			name:    "undefined",
+			src:     "A=1\nB=\"hello ${DOTENV_TEST_UNSET}\"",
+			wantErr: `undefined:2:10-11: undefined variable "DOTENV_TEST_UNSET"`,
+		},
+		{
+			name:    "unquoted whitespace",
+			src:     "A=hello world",
+			wantErr: `unquoted whitespace:1:3-14: unquoted value for A cannot contain whitespace, wrap it in quotes`,
+		},
+		{
+			name: "line continuation",
+			src:  "A=abc\\\ndef\\\r\nghi\nB=2",
+			want: map[string]string{"A": "abcdefghi", "B": "2"},
+		},
+		{
+			name: "multiline dedent",
+			src:  "A=x\nCERT=\"\"\"\n    -----BEGIN CERTIFICATE-----\n    MIIB\\t${A}\n    -----END CERTIFICATE-----\n    \"\"\"",
+			want: map[string]string{"A": "x", "CERT": "-----BEGIN CERTIFICATE-----\nMIIB\tx\n-----END CERTIFICATE-----"},
+		},
+		{
+			name: "multiline json",
+			src:  "KEY=\"\"\"\n  {\"a\": \"b\", \"c\": \"\\\"\"\"x\"}\n  \"\"\"\nB=2",
+			want: map[string]string{"KEY": `{"a": "b", "c": """"x"}`, "B": "2"},
+		},
+		{
+			name: "raw multiline",
+			src:  "A='''\n    ${DOTENV_TEST_USER} $(whoami)\n      \\n\n    '''",
+			want: map[string]string{"A": "${DOTENV_TEST_USER} $(whoami)\n  \\n"},
+		},
+		{
+			name:    "multiline error",
+			src:     "A=\"\"\"\n  ${DOTENV_TEST_UNSET}\n\"\"\"",
+			wantErr: `multiline error:1:3-4: undefined variable "DOTENV_TEST_UNSET"`,
+		},
+		{
+			name:    "unterminated raw multiline",
+			src:     "A='''\nabc\n",
+			wantErr: `unterminated raw multiline:3:1: unterminated multiline string`,
+		},
+		{
+			name:    "dotted key",
+			src:     "a.b=1",
+			wantErr: `dotted key:1:2-6: expected '=', got String`,
+		},
+		{
+			name: "escaped quotes",
+			src:  `PASSWORD="p@ss\"word\\"` + "\n" + `OTHER='it''s'` + "\n" + `RAW='a"b'`,
+			want: map[string]string{"PASSWORD": `p@ss"word\`, "OTHER": "it's", "RAW": `a"b`},
+		},
+	})
+}
+
+func TestCompose(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.Compose, []conformance{
+		{
+			name: "unquoted whitespace",
+			src:  "A=hello world",
+			want: map[string]string{"A": "hello world"},
+		},
+		{
+			name: "trailing whitespace trimmed",
+			src:  "A=   padded value   \nB=2",
+			want: map[string]string{"A": "padded value", "B": "2"},
+		},
+		{
+			name: "inline comment",
+			src:  "A=hello # A comment",
+			want: map[string]string{"A": "hello"},
+		},
+		{
+			name: "hash without whitespace",
+			src:  "A=hello#world\nB=#also",
+			want: map[string]string{"A": "hello#world", "B": "#also"},
+		},
+		{
+			name: "empty with comment",
+			src:  "A= # Nothing here",
+			want: map[string]string{"A": ""},
+		},
+		{
+			name: "double quoted",
+			src:  `A="hello # not a comment\n${DOTENV_TEST_USER}"`,
+			want: map[string]string{"A": "hello # not a comment\nme"},
+		},
+		{
+			name: "single quoted",
+			src:  `A='${DOTENV_TEST_USER}'`,
+			want: map[string]string{"A": "${DOTENV_TEST_USER}"},
+		},
+		{
+			name: "interpolation",
+			src:  "A=$DOTENV_TEST_USER and ${DOTENV_TEST_USER}",
+			want: map[string]string{"A": "me and me"},
+		},
+		{
+			name: "undefined",
+			src:  "A=x${DOTENV_TEST_UNSET}x",
+			want: map[string]string{"A": "xx"},
+		},
+		{
+			name: "dollar escape",
+			src:  "A=$$DOTENV_TEST_USER",
+			want: map[string]string{"A": "$DOTENV_TEST_USER"},
+		},
+		{
+			name: "no command substitution",
+			src:  "A=$(whoami)",
+			want: map[string]string{"A": "$(whoami)"},
+		},
+		{
+			name: "export",
+			src:  "export A=1",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "dotted key",
+			src:  "a.b=1",
+			want: map[string]string{"a.b": "1"},
+		},
+		{
+			name: "escaped quotes",
+			src:  `A="say \"hi\""`,
+			want: map[string]string{"A": `say "hi"`},
+		},
+	})
+}
+
+func TestDockerEnvFile(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.DockerEnvFile, []conformance{
+		{
+			name: "bare",
+			src:  "A=1",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "quotes are literal",
+			src:  `A="quoted"` + "\n" + `B='single'`,
+			want: map[string]string{"A": `"quoted"`, "B": "'single'"},
+		},
+		{
+			name: "whitespace is literal",
+			src:  "A= spaced out \nB=2",
+			want: map[string]string{"A": " spaced out ", "B": "2"},
+		},
+		{
+			name: "no inline comments",
+			src:  "A=value # not a comment",
+			want: map[string]string{"A": "value # not a comment"},
+		},
+		{
+			name: "no interpolation",
+			src:  "A=${DOTENV_TEST_USER} $(whoami)",
+			want: map[string]string{"A": "${DOTENV_TEST_USER} $(whoami)"},
+		},
+		{
+			name: "comments",
+			src:  "# A comment\nA=1",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "empty",
+			src:  "A=\nB=",
+			want: map[string]string{"A": "", "B": ""},
+		},
+		{
+			name: "passthrough",
+			src:  "DOTENV_TEST_USER\nDOTENV_TEST_UNSET\nA=1",
+			want: map[string]string{"DOTENV_TEST_USER": "me", "A": "1"},
+		},
+		{
+			name: "crlf",
+			src:  "A=1\r\nB=2\r\n",
+			want: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name:    "export",
+			src:     "export A=1",
+			wantErr: "export:1:1-7: the export keyword is not supported in the DockerEnvFile dialect",
+		},
+	})
+}
+
+func TestPythonDotenv(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.PythonDotenv, []conformance{
+		{
+			name: "unquoted whitespace",
+			src:  "A=hello world   # A comment",
+			want: map[string]string{"A": "hello world"},
+		},
+		{
+			name: "braced only",
+			src:  "A=$DOTENV_TEST_USER ${DOTENV_TEST_USER}",
+			want: map[string]string{"A": "$DOTENV_TEST_USER me"},
+		},
+		{
+			name: "default",
+			src:  `A="${DOTENV_TEST_UNSET:-fallback}"`,
+			want: map[string]string{"A": "fallback"},
+		},
+		{
+			name: "undefined",
+			src:  "A=${DOTENV_TEST_UNSET}",
+			want: map[string]string{"A": ""},
+		},
+		{
+			name: "double quoted escapes",
+			src:  `A="it\'s a\ttab \$"`,
+			want: map[string]string{"A": "it's a\ttab \\$"},
+		},
+		{
+			name: "single quoted",
+			src:  `A='${DOTENV_TEST_USER}\n'`,
+			want: map[string]string{"A": `${DOTENV_TEST_USER}\n`},
+		},
+		{
+			name: "no command substitution",
+			src:  "A=$(whoami)",
+			want: map[string]string{"A": "$(whoami)"},
+		},
+		{
+			name: "escaped quotes",
+			src:  `A="say \"hi\""` + "\n" + `B='it\'s \\ \n'`,
+			want: map[string]string{"A": `say "hi"`, "B": `it's \ \n`},
+		},
+	})
+}
+
+func TestNodeDotenv(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.NodeDotenv, []conformance{
+		{
+			name: "unquoted whitespace",
+			src:  "A=hello world",
+			want: map[string]string{"A": "hello world"},
+		},
+		{
+			name: "any hash is a comment",
+			src:  "A=abc#def\nB=#nothing",
+			want: map[string]string{"A": "abc", "B": ""},
+		},
+		{
+			name: "double quoted",
+			src:  `A="a\nb\tc # d"`,
+			want: map[string]string{"A": "a\nb\\tc # d"},
+		},
+		{
+			name: "backticks",
+			src:  "A=`it's \"quoted\"`",
+			want: map[string]string{"A": `it's "quoted"`},
+		},
+		{
+			name: "no interpolation",
+			src:  `A=${DOTENV_TEST_USER}` + "\n" + `B="$DOTENV_TEST_USER"`,
+			want: map[string]string{"A": "${DOTENV_TEST_USER}", "B": "$DOTENV_TEST_USER"},
+		},
+		{
+			name: "export",
+			src:  "export A=1",
+			want: map[string]string{"A": "1"},
+		},
+		{
+			name: "escaped quotes",
+			src:  `A="say \"hi\""`,
+			want: map[string]string{"A": `say \"hi\"`},
+		},
+	})
+}
+
+func TestPOSIXShell(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	runConformance(t, dotenv.POSIXShell, []conformance{
+		{
+			name: "interpolation",
+			src:  "A=$DOTENV_TEST_USER\nB=\"${A}!\"",
+			want: map[string]string{"A": "me", "B": "me!"},
+		},
+		{
+			name: "undefined",
+			src:  "A=x${DOTENV_TEST_UNSET}x",
+			want: map[string]string{"A": "xx"},
+		},
+		{
+			name: "unquoted escapes",
+			src:  `A=cost\$5`,
+			want: map[string]string{"A": "cost$5"},
+		},
+		{
+			name: "escaped whitespace",
+			src:  "A=hello\\ world\nB=\\ a\\\tb",
+			want: map[string]string{"A": "hello world", "B": " a\tb"},
+		},
+		{
+			name: "escaped hash",
+			src:  `A=\#not-a-comment` + "\n" + `B=a\ #b`,
+			want: map[string]string{"A": "#not-a-comment", "B": "a #b"},
+		},
+		{
+			name: "double quoted escapes",
+			src:  "A=\"\\$HOME \\n \\\\ \\`\"",
+			want: map[string]string{"A": "$HOME \\n \\ `"},
+		},
+		{
+			name: "no multiline strings",
+			src:  `A="""x"""`,
+			want: map[string]string{"A": "x"},
+		},
+		{
+			name: "concatenation",
+			src:  `A="double"'single'bare`,
+			want: map[string]string{"A": "doublesinglebare"},
+		},
+		{
+			name: "command substitution",
+			src:  "A=$(echo hello)",
+			want: map[string]string{"A": "hello"},
+		},
+		{
+			name:    "unquoted whitespace",
+			src:     "A=hello world",
+			wantErr: `unquoted whitespace:1:3-14: unquoted value for A cannot contain whitespace, wrap it in quotes`,
+		},
+		{
+			name: "line continuation",
+			src:  "A=abc\\\ndef\\\r\nghi\nB=2",
+			want: map[string]string{"A": "abcdefghi", "B": "2"},
+		},
+		{
+			name: "escaped quotes",
+			src:  `A="say \"hi\""` + "\n" + `B='it'\''s'`,
+			want: map[string]string{"A": `say "hi"`, "B": "it's"},
+		},
+	})
+}
+
+func FuzzDialects(f *testing.F) {
+	srcs, err := corpus.All()
+	test.Ok(f, err)
+
+	for _, src := range srcs {
+		f.Add(src)
+	}
+
+	f.Add("A=1\nexport B=two # Comment\n\n# Heading\nC = three\nD=\n")
+
+	// Outside of quoting, escaping, interpolation and a '#' inside a value, which is where
+	// they differ, every dialect but docker's (which has no comments or export) must agree
+	// with Strict
+	f.Fuzz(func(t *testing.T, src string) {
+		if strings.ContainsAny(src, "$\\\"'`") || hashInValue.MatchString(src) {
+			return
+		}
+
+		want, err := dotenv.Parse("fuzz", []byte(src), dotenv.Literal())
+		if err != nil {
+			return
+		}
+
+		for _, dialect := range []dotenv.Dialect{dotenv.Compose, dotenv.PythonDotenv, dotenv.NodeDotenv, dotenv.POSIXShell} {
+			got, err := dotenv.Parse("fuzz", []byte(src), dotenv.Literal(), dialect)
+			test.Ok(t, err, test.Context("%s rejected source Strict accepts", dialect))
+			test.EqualFunc(t, got.Map(), want.Map(), maps.Equal, test.Context("%s disagreed with Strict", dialect))
+		}
+	})
+}
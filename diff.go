@@ -0,0 +1,109 @@
+package dotenv
+
+import "slices"
+
+// DifferenceKind is the kind of a [Difference] between two sets of variables.
+type DifferenceKind int
+
+//go:generate stringer -type DifferenceKind -linecomment
+const (
+	LeftOnly     DifferenceKind = iota // left only
+	RightOnly                          // right only
+	ValueDiffers                       // value differs
+	OrderDiffers                       // order differs
+)
+
+// Difference is a single difference between two sets of variables, see [Compare].
+type Difference struct {
+	Key    string         // Name of the variable
+	Left   string         // Value on the left, "" for RightOnly
+	Right  string         // Value on the right, "" for LeftOnly
+	Kind   DifferenceKind // What kind of difference it is
+	Secret bool           // Whether the variable is secret on either side, if so take care showing Left and Right
+}
+
+// Compare compares two sets of variables e.g. from a .env and its .env.example,
+// returning the differences between them.
+//
+// The differences are reported in the order of the variables in left followed by
+// those only in right in their order. A variable defined on both sides may differ in
+// both value and order, in which case there is a [Difference] for each.
+//
+// Order differences are the variables that would have to move for the variables
+// common to both sides to appear in the same order, there are as few as possible.
+func Compare(left, right Env) []Difference {
+	var differences []Difference
+
+	moved := reordered(left, right)
+	for key, value := range left.All() {
+		secret := left.IsSecret(key) || right.IsSecret(key)
+
+		other, ok := right.Get(key)
+		if !ok {
+			differences = append(differences, Difference{Key: key, Left: value, Kind: LeftOnly, Secret: secret})
+			continue
+		}
+
+		if value != other {
+			differences = append(differences, Difference{Key: key, Left: value, Right: other, Kind: ValueDiffers, Secret: secret})
+		}
+
+		if moved[key] {
+			differences = append(differences, Difference{Key: key, Left: value, Right: other, Kind: OrderDiffers, Secret: secret})
+		}
+	}
+
+	for key, value := range right.All() {
+		if _, ok := left.Get(key); !ok {
+			differences = append(differences, Difference{Key: key, Right: value, Kind: RightOnly, Secret: right.IsSecret(key)})
+		}
+	}
+
+	return differences
+}
+
+// reordered returns the variables common to left and right that are out of order,
+// those not in the longest common subsequence of their keys.
+func reordered(left, right Env) map[string]bool {
+	a := slices.DeleteFunc(left.Keys(), func(key string) bool { _, ok := right.Get(key); return !ok })
+	b := slices.DeleteFunc(right.Keys(), func(key string) bool { _, ok := left.Get(key); return !ok })
+
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i, x := range slices.Backward(a) {
+		for j, y := range slices.Backward(b) {
+			if x == y {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	inOrder := make(map[string]bool, lengths[0][0])
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			inOrder[a[i]] = true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	moved := make(map[string]bool)
+	for _, key := range a {
+		if !inOrder[key] {
+			moved[key] = true
+		}
+	}
+
+	return moved
+}
@@ -0,0 +1,81 @@
+package dotenv_test
+
+import (
+	"slices"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name  string              // Name of the test case
+		left  string              // Source of the left .env
+		right string              // Source of the right .env
+		want  []dotenv.Difference // Expected differences
+	}{
+		{
+			name:  "same",
+			left:  "A=1\nB=2\n",
+			right: "A=1\nB=2\n",
+			want:  nil,
+		},
+		{
+			name:  "missing",
+			left:  "A=1\nB=2\n",
+			right: "B=2\nC=3\n",
+			want: []dotenv.Difference{
+				{Key: "A", Left: "1", Kind: dotenv.LeftOnly},
+				{Key: "C", Right: "3", Kind: dotenv.RightOnly},
+			},
+		},
+		{
+			name:  "values",
+			left:  "A=1\nAPI_TOKEN=abc\n",
+			right: "A=2\nAPI_TOKEN=xyz\n",
+			want: []dotenv.Difference{
+				{Key: "A", Left: "1", Right: "2", Kind: dotenv.ValueDiffers},
+				{Key: "API_TOKEN", Left: "abc", Right: "xyz", Kind: dotenv.ValueDiffers, Secret: true},
+			},
+		},
+		{
+			name:  "order",
+			left:  "A=1\nB=2\nC=3\nD=4\n",
+			right: "A=1\nC=3\nD=4\nB=2\n",
+			want: []dotenv.Difference{
+				{Key: "B", Left: "2", Right: "2", Kind: dotenv.OrderDiffers},
+			},
+		},
+		{
+			name:  "order ignores missing",
+			left:  "A=1\nX=9\nB=2\n",
+			right: "A=1\nB=2\nY=8\n",
+			want: []dotenv.Difference{
+				{Key: "X", Left: "9", Kind: dotenv.LeftOnly},
+				{Key: "Y", Right: "8", Kind: dotenv.RightOnly},
+			},
+		},
+		{
+			name:  "value and order",
+			left:  "A=1\nB=2\n",
+			right: "B=3\nA=1\n",
+			want: []dotenv.Difference{
+				{Key: "A", Left: "1", Right: "1", Kind: dotenv.OrderDiffers},
+				{Key: "B", Left: "2", Right: "3", Kind: dotenv.ValueDiffers},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, err := dotenv.Parse("left", []byte(tt.left))
+			test.Ok(t, err)
+
+			right, err := dotenv.Parse("right", []byte(tt.right))
+			test.Ok(t, err)
+
+			test.EqualFunc(t, dotenv.Compare(left, right), tt.want, slices.Equal)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Code generated by "stringer -type DifferenceKind -linecomment"; DO NOT EDIT.
+
+package dotenv
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[LeftOnly-0]
+	_ = x[RightOnly-1]
+	_ = x[ValueDiffers-2]
+	_ = x[OrderDiffers-3]
+}
+
+const _DifferenceKind_name = "left onlyright onlyvalue differsorder differs"
+
+var _DifferenceKind_index = [...]uint8{0, 9, 19, 32, 45}
+
+func (i DifferenceKind) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_DifferenceKind_index)-1 {
+		return "DifferenceKind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _DifferenceKind_name[_DifferenceKind_index[idx]:_DifferenceKind_index[idx+1]]
+}
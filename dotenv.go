@@ -1,7 +1,325 @@
-// Package dotenv is a placeholder for something cool.
+// Package dotenv loads environment variables from .env files.
+//
+// The syntax of a .env file varies from tool to tool, by default dotenv uses its own
+// [Strict] dialect but the rules of other ecosystems can be selected by passing a
+// [Dialect] as an [Option].
 package dotenv // import "go.followtheprocess.codes/dotenv"
 
-// Hello returns a welcome message for the project.
-func Hello() string {
-	return "Hello dotenv"
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/parser"
+)
+
+// DefaultFile is the file loaded by [Load] if no other files are specified.
+const DefaultFile = ".env"
+
+// Env is a set of environment variables resolved from a .env file.
+//
+// The zero value is an empty Env ready to use.
+//
+// Variables classified as secret (see [SecretKeys]) are redacted when an Env is
+// formatted or logged, but [Env.Get] and friends always return the real values.
+type Env struct {
+	values    map[string]string   // Variable values by name
+	comments  map[string]string   // Inline comments documenting variables, by name
+	positions map[string]Position // Where each variable's value was defined, by name
+	secrets   map[string]bool     // Names of the variables that are secret
+	keys      []string            // Variable names in the order they were first defined
+}
+
+// Get returns the value of the variable key, and reports whether it was set.
+func (e Env) Get(key string) (string, bool) {
+	value, ok := e.values[key]
+	return value, ok
+}
+
+// Comment returns the inline comment documenting the variable key e.g. "The port"
+// for 'PORT=8080 # The port', or "" if there isn't one.
+func (e Env) Comment(key string) string {
+	return e.comments[key]
+}
+
+// IsSecret reports whether the variable key is a secret, either because its name
+// matches one of the secret patterns or it was annotated with "@secret" in a comment.
+func (e Env) IsSecret(key string) bool {
+	return e.secrets[key]
+}
+
+// String implements [fmt.Stringer] for an [Env], rendering the variables in .env
+// syntax one per line, with the values of secrets redacted.
+func (e Env) String() string {
+	b := &strings.Builder{}
+	for key, value := range e.redacted() {
+		if b.Len() != 0 {
+			b.WriteByte('\n')
+		}
+
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quote(value))
+	}
+
+	return b.String()
+}
+
+// GoString implements [fmt.GoStringer] for an [Env], so that formatting with "%#v"
+// also redacts the values of secrets.
+func (e Env) GoString() string {
+	b := &strings.Builder{}
+	b.WriteString("dotenv.Env{")
+
+	for key, value := range e.redacted() {
+		if b.Len() != len("dotenv.Env{") {
+			b.WriteString(", ")
+		}
+
+		fmt.Fprintf(b, "%q: %q", key, value)
+	}
+
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// LogValue implements [slog.LogValuer] for an [Env], logging the variables as a
+// group with the values of secrets redacted.
+func (e Env) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(e.keys))
+	for key, value := range e.redacted() {
+		attrs = append(attrs, slog.String(key, value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Keys returns the names of the variables in the Env, in the order they were
+// first defined.
+func (e Env) Keys() []string {
+	return slices.Clone(e.keys)
+}
+
+// Len returns the number of variables in the Env.
+func (e Env) Len() int {
+	return len(e.keys)
+}
+
+// All returns an iterator over the variables in the Env, in the order they
+// were first defined.
+func (e Env) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, key := range e.keys {
+			if !yield(key, e.values[key]) {
+				return
+			}
+		}
+	}
+}
+
+// Map returns the variables in the Env as a map of name to value.
+func (e Env) Map() map[string]string {
+	return maps.Clone(e.values)
+}
+
+// redacted returns an iterator over the variables in the Env, like [Env.All], but with the
+// values of secrets replaced.
+func (e Env) redacted() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for key, value := range e.All() {
+			if e.secrets[key] {
+				value = secret.Redacted
+			}
+
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// set sets the value of key in env, preserving its position if it was already defined.
+func set(env *Env, key, value string) {
+	if env.values == nil {
+		env.values = make(map[string]string)
+	}
+
+	if _, exists := env.values[key]; !exists {
+		env.keys = append(env.keys, key)
+	}
+
+	env.values[key] = value
+}
+
+// conceal marks key as a secret in env.
+func conceal(env *Env, key string) {
+	if env.secrets == nil {
+		env.secrets = make(map[string]bool)
+	}
+
+	env.secrets[key] = true
+}
+
+// locate records pos as where the value of key in env was defined.
+func locate(env *Env, key string, pos Position) {
+	if env.positions == nil {
+		env.positions = make(map[string]Position)
+	}
+
+	env.positions[key] = pos
+}
+
+// document records comment as the documentation for key in env.
+func document(env *Env, key, comment string) {
+	if env.comments == nil {
+		env.comments = make(map[string]string)
+	}
+
+	env.comments[key] = comment
+}
+
+// Parse parses and evaluates the .env source src, returning the resolved variables.
+//
+// The name is used in error messages and is typically the name of the file src
+// was read from.
+//
+// Interpolated variables are looked up first in the variables already defined in src
+// and then in the process environment (or the [Environment] given by [In]). Parse does
+// not modify the environment.
+func Parse(name string, src []byte, options ...Option) (Env, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return Env{}, err
+	}
+
+	return resolve(name, src, cfg)
+}
+
+// Read reads, parses and evaluates the .env file at path, returning the resolved variables.
+//
+// It does not modify the environment.
+func Read(path string, options ...Option) (Env, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return Env{}, err
+	}
+
+	return readFile(path, cfg)
+}
+
+// Load reads the .env files given by the [File] option (or [DefaultFile] if none
+// were given) and sets the variables they contain in the process environment, or the
+// [Environment] given by [In].
+//
+// Files are loaded in order, so variables in later files may reference those in earlier
+// ones. Variables already set in the environment are left alone unless the [Overwrite]
+// option is passed.
+func Load(options ...Option) error {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return err
+	}
+
+	files := cfg.files
+	if len(files) == 0 {
+		files = []string{DefaultFile}
+	}
+
+	for _, file := range files {
+		env, err := readFile(file, cfg)
+		if err != nil {
+			return err
+		}
+
+		for key, value := range env.All() {
+			if _, exists := cfg.environment.Lookup(key); exists && !cfg.overwrite {
+				continue
+			}
+
+			if err := cfg.environment.Set(key, value); err != nil {
+				return fmt.Errorf("could not set %s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFile implements [Read] with an already built config.
+func readFile(path string, cfg config) (Env, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Env{}, err
+	}
+
+	return resolve(path, src, cfg)
+}
+
+// resolve implements [Parse] with an already built config.
+func resolve(name string, src []byte, cfg config) (Env, error) {
+	ev, err := evaluateFile(name, src, cfg)
+	if err != nil {
+		return Env{}, err
+	}
+
+	return ev.env, nil
+}
+
+// evaluateFile parses and evaluates the .env source src, returning the evaluator
+// holding the results.
+func evaluateFile(name string, src []byte, cfg config) (*evaluator, error) {
+	ev, err := newEvaluator(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ev.evaluate(); err != nil {
+		return nil, err
+	}
+
+	return ev, nil
+}
+
+// newEvaluator parses the .env source src, returning an evaluator for it that has not
+// yet evaluated anything.
+func newEvaluator(name string, src []byte, cfg config) (*evaluator, error) {
+	file, err := parseFile(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	classifier := secret.New(cfg.secrets...)
+
+	ev := &evaluator{
+		name:        name,
+		src:         src,
+		file:        file,
+		decrypter:   cfg.decrypter,
+		resolvers:   cfg.resolvers,
+		environment: cfg.environment,
+		classifier:  classifier,
+		secrets:     classifier.File(file),
+		dialect:     cfg.dialect,
+		literal:     cfg.literal,
+	}
+
+	return ev, nil
+}
+
+// parseFile parses the .env source src into a syntax tree according to cfg.
+func parseFile(name string, src []byte, cfg config) (ast.File, error) {
+	options := []parser.Option{parser.WithDialect(syntax.Dialect(cfg.dialect))}
+	if cfg.spaces {
+		options = append(options, parser.WithLineValues())
+	}
+
+	return parser.New(name, src, nil, options...).Parse()
 }
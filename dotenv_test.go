@@ -1,16 +1,363 @@
 package dotenv_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/corpus"
+	"go.followtheprocess.codes/test"
 )
 
-func TestHello(t *testing.T) {
-	got := dotenv.Hello()
-	want := "Hello dotenv"
+const readme = `
+# This is a comment and is ignored by the parser completely
+NUMBER_OF_THINGS=123 # Comments can also go on lines
+USERNAME=mysuperuser
 
-	if got != want {
-		t.Errorf("got %s, wanted %s", got, want)
+# Command substitution
+API_KEY=$(echo secret)
+
+# Variable interpolation
+EMAIL=${USERNAME}@email.com
+CACHE_DIR=${DOTENV_TEST_HOME}/.cache
+DATABASE_URL="postgres://${USERNAME}@localhost/my_database"
+
+# Single quotes force the string to be treated as literal
+# no interpolation or command substitution will happen here
+LITERAL='${USER} should show up literally'
+
+# Escape sequences work as you'd expect
+ESCAPE_ME="Newline\n and a tab\t etc."
+
+# You can even use the export keyword to retain compatibility with e.g. bash
+export SOMETHING=yes
+`
+
+func TestParse(t *testing.T) {
+	t.Setenv("DOTENV_TEST_HOME", "/home/me")
+
+	env, err := dotenv.Parse("readme", []byte(readme))
+	test.Ok(t, err)
+
+	want := []string{
+		"NUMBER_OF_THINGS=123",
+		"USERNAME=mysuperuser",
+		"API_KEY=secret",
+		"EMAIL=mysuperuser@email.com",
+		"CACHE_DIR=/home/me/.cache",
+		"DATABASE_URL=postgres://mysuperuser@localhost/my_database",
+		"LITERAL=${USER} should show up literally",
+		"ESCAPE_ME=Newline\n and a tab\t etc.",
+		"SOMETHING=yes",
+	}
+
+	got := make([]string, 0, env.Len())
+	for key, value := range env.All() {
+		got = append(got, key+"="+value)
+	}
+
+	test.EqualFunc(t, got, want, slices.Equal)
+}
+
+func TestEnv(t *testing.T) {
+	env, err := dotenv.Parse("env", []byte("B=1\nA=2\nB=3\n"))
+	test.Ok(t, err)
+
+	test.Equal(t, env.Len(), 2)
+	test.EqualFunc(t, env.Keys(), []string{"B", "A"}, slices.Equal, test.Context("keys should be in definition order"))
+
+	value, ok := env.Get("B")
+	test.True(t, ok)
+	test.Equal(t, value, "3", test.Context("later definitions should win"))
+
+	_, ok = env.Get("MISSING")
+	test.False(t, ok)
+
+	var empty dotenv.Env
+	test.Equal(t, empty.Len(), 0)
+
+	_, ok = empty.Get("A")
+	test.False(t, ok)
+}
+
+func TestInlineComments(t *testing.T) {
+	src := "PORT=8080 # The port to listen on\nURL=https://example.com/#top\nNAME=\"me\" #Who\nHOST=localhost\n"
+
+	env, err := dotenv.Parse("comments", []byte(src))
+	test.Ok(t, err)
+
+	url, _ := env.Get("URL")
+	test.Equal(t, url, "https://example.com/#top", test.Context("'#' not preceded by whitespace is part of the value"))
+
+	port, _ := env.Get("PORT")
+	test.Equal(t, port, "8080")
+
+	test.Equal(t, env.Comment("PORT"), "The port to listen on")
+	test.Equal(t, env.Comment("NAME"), "Who")
+	test.Equal(t, env.Comment("HOST"), "")
+	test.Equal(t, env.Comment("URL"), "")
+}
+
+func TestAllowSpaces(t *testing.T) {
+	t.Setenv("DOTENV_TEST_USER", "me")
+
+	src := "GREETING=hello big world   # A comment\nWHO=hi $DOTENV_TEST_USER\nLONG=one \\\n  two\nQUOTED=\"x\"\n"
+
+	for _, dialect := range []dotenv.Dialect{dotenv.Strict, dotenv.POSIXShell} {
+		t.Run(dialect.String(), func(t *testing.T) {
+			env, err := dotenv.Parse("spaces", []byte(src), dialect, dotenv.AllowSpaces())
+			test.Ok(t, err)
+
+			want := map[string]string{
+				"GREETING": "hello big world",
+				"WHO":      "hi me",
+				"LONG":     "one   two",
+				"QUOTED":   "x",
+			}
+
+			test.EqualFunc(t, env.Map(), want, maps.Equal)
+			test.Equal(t, env.Comment("GREETING"), "A comment")
+		})
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	src := "A=\"say \\\"hi\\\"\"\nB='${raw}'\nC=$(exit 1)\nD=\"${DOTENV_TEST_UNDEFINED}/x\"\nE=cost\\$5\n"
+
+	env, err := dotenv.Parse("literal", []byte(src), dotenv.Literal())
+	test.Ok(t, err)
+
+	want := map[string]string{
+		"A": `say "hi"`,
+		"B": "${raw}",
+		"C": "$(exit 1)",
+		"D": `"${DOTENV_TEST_UNDEFINED}/x"`,
+		"E": `cost\$5`,
 	}
+
+	test.EqualFunc(t, env.Map(), want, maps.Equal)
+}
+
+func TestSecrets(t *testing.T) {
+	src := `API_TOKEN=abcdef1234
+# The connection string
+# @secret
+DSN=postgres://me:pw@localhost
+PORT=8080 # Not a secret
+SESSION=xyz-789-abc # @secret
+DATABASE_URL=postgres://localhost
+`
+
+	env, err := dotenv.Parse("secrets", []byte(src), dotenv.SecretKeys("database_*"))
+	test.Ok(t, err)
+
+	for _, key := range []string{"API_TOKEN", "DSN", "SESSION", "DATABASE_URL"} {
+		test.True(t, env.IsSecret(key), test.Context("%s should be secret", key))
+	}
+
+	test.False(t, env.IsSecret("PORT"))
+
+	token, _ := env.Get("API_TOKEN")
+	test.Equal(t, token, "abcdef1234", test.Context("Get should return the real value"))
+
+	wantString := "API_TOKEN=[REDACTED]\nDSN=[REDACTED]\nPORT=8080\nSESSION=[REDACTED]\nDATABASE_URL=[REDACTED]"
+	test.Equal(t, env.String(), wantString)
+	test.Equal(t, fmt.Sprint(env), wantString)
+
+	wantGo := `dotenv.Env{"API_TOKEN": "[REDACTED]", "DSN": "[REDACTED]", "PORT": "8080", "SESSION": "[REDACTED]", "DATABASE_URL": "[REDACTED]"}`
+	test.Equal(t, fmt.Sprintf("%#v", env), wantGo)
+
+	logs := &bytes.Buffer{}
+	slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})).Info("loaded", "env", env)
+
+	test.Equal(
+		t,
+		logs.String(),
+		"level=INFO msg=loaded env.API_TOKEN=[REDACTED] env.DSN=[REDACTED] env.PORT=8080 env.SESSION=[REDACTED] env.DATABASE_URL=[REDACTED]\n",
+	)
+}
+
+func TestSecretsRedactedFromErrors(t *testing.T) {
+	t.Setenv("DOTENV_TEST_TOKEN", "from-the-environment")
+
+	tests := []struct {
+		name    string // Name of the test case
+		src     string // .env source text
+		wantErr string // Expected error
+	}{
+		{
+			name:    "file",
+			src:     "API_KEY=abcdef1234\nURL=${MISSING:?key is $API_KEY}\n",
+			wantErr: "secrets.env:2:5-6: MISSING: key is [REDACTED]",
+		},
+		{
+			name:    "environment",
+			src:     "URL=${MISSING:?token is $DOTENV_TEST_TOKEN}\n",
+			wantErr: "secrets.env:1:5-6: MISSING: token is [REDACTED]",
+		},
+		{
+			name:    "not secret",
+			src:     "USER=someone\nURL=${MISSING:?user is $USER}\n",
+			wantErr: "secrets.env:2:5-6: MISSING: user is someone",
+		},
+		{
+			name:    "referenced name",
+			src:     "API_KEY=abc${DOTENV_TEST_UNDEFINED}\n",
+			wantErr: `secrets.env:1:12-13: undefined variable "DOTENV_TEST_UNDEFINED"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dotenv.Parse("secrets.env", []byte(tt.src))
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSecretKeysBadPattern(t *testing.T) {
+	_, err := dotenv.Parse("secrets.env", []byte("A=1\n"), dotenv.SecretKeys("[oops"))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `bad secret pattern "[oops": syntax error in pattern`)
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	_, err := dotenv.Parse("bad.env", []byte("A=1\nB C\n"))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `bad.env:2:3-4: expected '=', got Ident`)
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	test.Ok(t, os.WriteFile(path, []byte("A=1\n"), 0o600))
+
+	env, err := dotenv.Read(path)
+	test.Ok(t, err)
+
+	value, ok := env.Get("A")
+	test.True(t, ok)
+	test.Equal(t, value, "1")
+
+	_, err = dotenv.Read(filepath.Join(t.TempDir(), "missing"))
+	test.Err(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, ".env")
+	second := filepath.Join(dir, ".env.local")
+
+	test.Ok(t, os.WriteFile(first, []byte("DOTENV_TEST_A=first\nDOTENV_TEST_B=first\n"), 0o600))
+	test.Ok(t, os.WriteFile(second, []byte("DOTENV_TEST_C=${DOTENV_TEST_A}-second\n"), 0o600))
+
+	t.Run("no overwrite", func(t *testing.T) {
+		t.Setenv("DOTENV_TEST_A", "")
+		t.Setenv("DOTENV_TEST_B", "existing")
+		t.Setenv("DOTENV_TEST_C", "")
+		os.Unsetenv("DOTENV_TEST_A")
+		os.Unsetenv("DOTENV_TEST_C")
+
+		err := dotenv.Load(dotenv.File(first), dotenv.File(second))
+		test.Ok(t, err)
+
+		test.Equal(t, os.Getenv("DOTENV_TEST_A"), "first")
+		test.Equal(t, os.Getenv("DOTENV_TEST_B"), "existing")
+		test.Equal(t, os.Getenv("DOTENV_TEST_C"), "first-second")
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		t.Setenv("DOTENV_TEST_A", "existing")
+		t.Setenv("DOTENV_TEST_B", "existing")
+
+		err := dotenv.Load(dotenv.File(first), dotenv.Overwrite())
+		test.Ok(t, err)
+
+		test.Equal(t, os.Getenv("DOTENV_TEST_A"), "first")
+		test.Equal(t, os.Getenv("DOTENV_TEST_B"), "first")
+	})
+
+	t.Run("default file", func(t *testing.T) {
+		t.Chdir(dir)
+		t.Setenv("DOTENV_TEST_A", "")
+		os.Unsetenv("DOTENV_TEST_A")
+
+		test.Ok(t, dotenv.Load())
+		test.Equal(t, os.Getenv("DOTENV_TEST_A"), "first")
+	})
+
+	t.Run("bad options", func(t *testing.T) {
+		test.Err(t, dotenv.Load(dotenv.File("")))
+		test.Err(t, dotenv.Load(dotenv.Dialect(42)))
+	})
+}
+
+func FuzzString(f *testing.F) {
+	srcs, err := corpus.All()
+	test.Ok(f, err)
+
+	for _, src := range srcs {
+		f.Add(src)
+	}
+
+	f.Add(readme)
+
+	// Env.String must produce source that parses back to the same variables and
+	// stringifies identically. Parsing literally means no commands are run
+	f.Fuzz(func(t *testing.T, src string) {
+		env, err := dotenv.Parse("fuzz", []byte(src), dotenv.Literal())
+		if err != nil {
+			// Every error must point somewhere real
+			var syntaxErr syntax.Error
+			for _, err := range unjoin(err) {
+				test.True(t, errors.As(err, &syntaxErr), test.Context("error %v was not a syntax.Error", err))
+				test.True(t, syntaxErr.Pos.IsValid(), test.Context("error reported at invalid position %s", syntaxErr.Pos))
+			}
+
+			return
+		}
+
+		formatted := env.String()
+
+		reparsed, err := dotenv.Parse("formatted", []byte(formatted), dotenv.In(dotenv.Memory(nil)))
+		test.Ok(t, err, test.Context("String output did not parse:\n%s", formatted))
+
+		test.Equal(t, reparsed.String(), formatted, test.Context("String was not idempotent"))
+		test.EqualFunc(t, reparsed.Keys(), env.Keys(), slices.Equal, test.Context("variables were not preserved"))
+
+		for key, value := range env.All() {
+			if env.IsSecret(key) {
+				// Redacted when formatted
+				continue
+			}
+
+			got, _ := reparsed.Get(key)
+			test.Equal(t, got, value, test.Context("value of %s was not preserved", key))
+		}
+	})
+}
+
+// unjoin returns the errors joined together in err by [errors.Join], or just err.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
 }
@@ -0,0 +1,176 @@
+// Package dotenvtest provides helpers for tests that use .env files.
+//
+// [Load] and [LoadString] set variables with [testing.T.Setenv] so they are restored
+// when the test finishes, and [Golden] and [GoldenError] compare resolved variables
+// and errors against golden files:
+//
+//	var update = flag.Bool("update", false, "Update golden files")
+//
+//	func TestConfig(t *testing.T) {
+//		env := dotenvtest.Load(t, "testdata/app.env")
+//		dotenvtest.Golden(t, "testdata/app.golden", env, dotenvtest.Update(*update))
+//	}
+//
+// [Resolver] fakes a secret manager, so loading files with references to it can be
+// tested without it:
+//
+//	vault := dotenvtest.Resolver(map[string]string{"op://vault/db/password": "hunter2"})
+//	env := dotenvtest.Load(t, "testdata/app.env", dotenv.Register("op", vault))
+//
+// Like t.Setenv, Load and LoadString cannot be used in parallel tests. Parallel tests
+// should load into their own environment with [dotenv.In] and [dotenv.Memory] instead.
+package dotenvtest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+const (
+	goldenPerm    = 0o644 // Permissions of golden files
+	goldenDirPerm = 0o755 // Permissions of directories created for golden files
+)
+
+// config holds the configuration for the golden file helpers.
+type config struct {
+	update bool // Write the golden file rather than comparing against it
+}
+
+// Option is a configuration option for [Golden] and [GoldenError].
+type Option func(cfg *config)
+
+// Update is an [Option] that, when update is true, makes [Golden] and [GoldenError]
+// write the golden file rather than compare against it. It's typically set from a
+// flag so golden files can be regenerated with e.g. 'go test ./... -update'.
+func Update(update bool) Option {
+	return func(cfg *config) {
+		cfg.update = update
+	}
+}
+
+// Load reads the .env file at path and sets each of its variables with tb.Setenv,
+// returning the resolved variables. Any error fails the test immediately.
+func Load(tb testing.TB, path string, options ...dotenv.Option) dotenv.Env {
+	tb.Helper()
+
+	env, err := dotenv.Read(path, options...)
+	if err != nil {
+		tb.Fatalf("dotenvtest.Load(%s): %v", path, err)
+	}
+
+	setenv(tb, env)
+
+	return env
+}
+
+// LoadString parses the .env source src and sets each of its variables with tb.Setenv,
+// returning the resolved variables. Any error fails the test immediately.
+func LoadString(tb testing.TB, src string, options ...dotenv.Option) dotenv.Env {
+	tb.Helper()
+
+	env, err := dotenv.Parse(tb.Name(), []byte(src), options...)
+	if err != nil {
+		tb.Fatalf("dotenvtest.LoadString: %v", err)
+	}
+
+	setenv(tb, env)
+
+	return env
+}
+
+// Golden compares env against the golden file at path, failing the test with a diff
+// if they differ.
+//
+// The variables are written in .env syntax one per line, as by [dotenv.Env.String],
+// so the values of secrets are redacted and never end up in golden files.
+func Golden(tb testing.TB, path string, env dotenv.Env, options ...Option) {
+	tb.Helper()
+
+	got := env.String()
+	if got != "" {
+		got += "\n"
+	}
+
+	compare(tb, path, got, options...)
+}
+
+// GoldenError compares the message of err, typically a syntax error, against the
+// golden file at path, failing the test with a diff if they differ or if err is nil.
+func GoldenError(tb testing.TB, path string, err error, options ...Option) {
+	tb.Helper()
+
+	if err == nil {
+		tb.Fatalf("dotenvtest.GoldenError(%s): expected an error, got nil", path)
+		return
+	}
+
+	compare(tb, path, err.Error()+"\n", options...)
+}
+
+// compare compares got against the golden file at path, or writes it if updating.
+func compare(tb testing.TB, path, got string, options ...Option) {
+	tb.Helper()
+
+	var cfg config
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	if cfg.update {
+		if err := os.MkdirAll(filepath.Dir(path), goldenDirPerm); err != nil {
+			tb.Fatalf("could not create golden file directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(got), goldenPerm); err != nil {
+			tb.Fatalf("could not update golden file: %v", err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		tb.Fatalf("golden file %s does not exist, run with the Update option to create it", path)
+		return
+	}
+
+	if err != nil {
+		tb.Fatalf("could not read golden file: %v", err)
+		return
+	}
+
+	test.Diff(tb, got, string(want))
+}
+
+// Resolver returns a fake [dotenv.Resolver] resolving each reference in values, keyed by
+// the reference in full e.g. "op://vault/item/field", to its value. A reference not in
+// values is an error.
+func Resolver(values map[string]string) dotenv.Resolver {
+	f := func(ref *url.URL) (string, error) {
+		value, ok := values[ref.String()]
+		if !ok {
+			return "", fmt.Errorf("dotenvtest.Resolver has no value for %s", ref)
+		}
+
+		return value, nil
+	}
+
+	return dotenv.ResolverFunc(f)
+}
+
+// setenv sets every variable in env with tb.Setenv.
+func setenv(tb testing.TB, env dotenv.Env) {
+	tb.Helper()
+
+	for key, value := range env.All() {
+		tb.Setenv(key, value)
+	}
+}
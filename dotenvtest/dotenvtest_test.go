@@ -0,0 +1,119 @@
+package dotenvtest_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/dotenvtest"
+	"go.followtheprocess.codes/test"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		env := dotenvtest.Load(t, filepath.Join("testdata", "app.env"))
+
+		test.Equal(t, env.Len(), 3)
+	})
+
+	_, ok := os.LookupEnv("GREETING")
+	test.False(t, ok, test.Context("variables should be restored after the test"))
+}
+
+func TestLoadString(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		dotenvtest.LoadString(t, "DOTENVTEST_A=1\nDOTENVTEST_B=${DOTENVTEST_A}2\n")
+		test.Equal(t, os.Getenv("DOTENVTEST_B"), "12")
+	})
+
+	_, ok := os.LookupEnv("DOTENVTEST_B")
+	test.False(t, ok, test.Context("variables should be restored after the test"))
+
+	tb := &fakeTB{TB: t}
+	dotenvtest.LoadString(tb, "A B\n")
+	test.True(t, tb.failed)
+	test.Equal(t, tb.msg, "dotenvtest.LoadString: TestLoadString:1:3-4: expected '=', got Ident")
+}
+
+func TestGolden(t *testing.T) {
+	env, err := dotenv.Read(filepath.Join("testdata", "app.env"))
+	test.Ok(t, err)
+
+	dotenvtest.Golden(t, filepath.Join("testdata", "app.golden"), env)
+
+	// Mismatches fail
+	other, err := dotenv.Parse("other", []byte("PORT=9090\n"))
+	test.Ok(t, err)
+
+	tb := &fakeTB{TB: t}
+	dotenvtest.Golden(tb, filepath.Join("testdata", "app.golden"), other)
+	test.True(t, tb.failed)
+
+	// Updating writes the file, creating directories as needed
+	path := filepath.Join(t.TempDir(), "nested", "other.golden")
+	dotenvtest.Golden(t, path, other, dotenvtest.Update(true))
+
+	contents, err := os.ReadFile(path)
+	test.Ok(t, err)
+	test.Equal(t, string(contents), "PORT=9090\n")
+
+	dotenvtest.Golden(t, path, other)
+
+	tb = &fakeTB{TB: t}
+	dotenvtest.Golden(tb, filepath.Join(t.TempDir(), "missing.golden"), other)
+	test.True(t, tb.failed)
+}
+
+func TestGoldenError(t *testing.T) {
+	_, err := dotenv.Parse("bad.env", []byte("A=1\nB C\n"))
+	dotenvtest.GoldenError(t, filepath.Join("testdata", "error.golden"), err)
+
+	tb := &fakeTB{TB: t}
+	dotenvtest.GoldenError(tb, filepath.Join("testdata", "error.golden"), nil)
+	test.True(t, tb.failed)
+
+	tb = &fakeTB{TB: t}
+	dotenvtest.GoldenError(tb, filepath.Join("testdata", "error.golden"), errors.New("something else"))
+	test.True(t, tb.failed)
+}
+
+// fakeTB is a [testing.TB] that records failures rather than failing the test.
+type fakeTB struct {
+	testing.TB
+
+	msg    string // The failure message
+	failed bool   // Whether the test failed
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failed = true
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func TestResolver(t *testing.T) {
+	vault := dotenvtest.Resolver(map[string]string{"op://vault/db/password": "hunter2"})
+
+	src := []byte("DB_PASSWORD=op://vault/db/password\nDSN=postgres://me:${ref:op://vault/db/password}@db\n")
+
+	env, err := dotenv.Parse("app.env", src, dotenv.Register("op", vault))
+	test.Ok(t, err)
+
+	password, _ := env.Get("DB_PASSWORD")
+	test.Equal(t, password, "hunter2")
+
+	dsn, _ := env.Get("DSN")
+	test.Equal(t, dsn, "postgres://me:hunter2@db")
+
+	_, err = dotenv.Parse("app.env", []byte("HOST=op://vault/api/host\n"), dotenv.Register("op", vault))
+	test.Err(t, err)
+	test.Equal(
+		t,
+		err.Error(),
+		"app.env:1:6-25: could not resolve op://vault/api/host: dotenvtest.Resolver has no value for op://vault/api/host",
+	)
+}
@@ -0,0 +1,133 @@
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shell is a shell whose syntax an [Encoder] writes.
+type Shell int
+
+//go:generate stringer -type Shell -linecomment
+const (
+	Bash       Shell = iota // bash
+	Zsh                     // zsh
+	Fish                    // fish
+	PowerShell              // powershell
+	Nushell                 // nushell
+)
+
+// Encoder writes variables as shell commands that set them, for a shell to evaluate
+// e.g. 'eval "$(dotenv export)"'.
+//
+// Values are quoted so that they're taken literally whatever they contain, including
+// quotes, newlines and anything the shell would otherwise expand.
+type Encoder struct {
+	w     io.Writer // Where the commands are written
+	shell Shell     // The shell whose syntax to use
+}
+
+// NewEncoder returns an [Encoder] writing commands for shell to w.
+func NewEncoder(w io.Writer, shell Shell) *Encoder {
+	return &Encoder{w: w, shell: shell}
+}
+
+// Encode writes a command setting and exporting each variable in env, one per line
+// in the order they were defined.
+//
+// The real values of secrets are written, as they're needed by whatever runs in the
+// shell. Encode returns an error without writing anything if a variable's name isn't
+// a valid name in the shell, or the shell isn't one of the defined [Shell]s.
+func (e *Encoder) Encode(env Env) error {
+	if e.shell < Bash || e.shell > Nushell {
+		return fmt.Errorf("invalid shell: %s", e.shell)
+	}
+
+	b := &strings.Builder{}
+	for key, value := range env.All() {
+		if !isName(key) {
+			return fmt.Errorf("cannot export %q, it is not a valid %s variable name", key, e.shell)
+		}
+
+		switch e.shell {
+		case Fish:
+			fmt.Fprintf(b, "set -gx %s %s\n", key, fishQuote(value))
+		case PowerShell:
+			fmt.Fprintf(b, "$env:%s = %s\n", key, powerShellQuote(value))
+		case Nushell:
+			fmt.Fprintf(b, "$env.%s = %s\n", key, nushellQuote(value))
+		default:
+			// bash and zsh share POSIX quoting
+			fmt.Fprintf(b, "export %s=%s\n", key, posixQuote(value))
+		}
+	}
+
+	_, err := io.WriteString(e.w, b.String())
+
+	return err
+}
+
+// isName reports whether key is a valid variable name in every supported shell, a
+// letter or underscore followed by letters, digits and underscores.
+func isName(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := range len(key) {
+		char := key[i]
+
+		isValid := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_' ||
+			(i > 0 && char >= '0' && char <= '9')
+		if !isValid {
+			return false
+		}
+	}
+
+	return true
+}
+
+// posixQuote single quotes value for a POSIX shell, where nothing inside single quotes
+// is special. A single quote is written by ending the quotes, adding an escaped quote
+// and starting them again.
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote single quotes value for fish, where only \' and \\ are escape sequences
+// inside single quotes.
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
+
+// powerShellQuote single quotes value for PowerShell, where a quote is written by
+// doubling it. PowerShell also accepts the typographic single quotes as quotes, so
+// they're doubled too.
+func powerShellQuote(value string) string {
+	replacer := strings.NewReplacer(
+		"'", "''",
+		"‘", "‘‘",
+		"’", "’’",
+		"‚", "‚‚",
+		"‛", "‛‛",
+	)
+
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// nushellQuote quotes value for nushell. Single quoted strings have no escape sequences
+// and so can't contain a single quote, for those a raw string is used, delimited by
+// enough '#' that the value can't end it early.
+func nushellQuote(value string) string {
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	hashes := "#"
+	for strings.Contains(value, "'"+hashes) {
+		hashes += "#"
+	}
+
+	return "r" + hashes + "'" + value + "'" + hashes
+}
@@ -0,0 +1,173 @@
+package dotenv_test
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestEncoder(t *testing.T) {
+	src := []byte(`PLAIN=value
+QUOTES="it's \"quoted\""
+MULTILINE="one\ntwo"
+EXPANSION='$HOME $(whoami) ` + "`id`" + ` \n'
+EMPTY=
+`)
+
+	env, err := dotenv.Parse("test.env", src)
+	test.Ok(t, err)
+
+	tests := []struct {
+		name  string       // Name of the test case
+		want  string       // Expected output
+		shell dotenv.Shell // Shell to encode for
+	}{
+		{
+			name:  "bash",
+			shell: dotenv.Bash,
+			want: `export PLAIN='value'
+export QUOTES='it'\''s "quoted"'
+export MULTILINE='one
+two'
+export EXPANSION='$HOME $(whoami) ` + "`id`" + ` \n'
+export EMPTY=''
+`,
+		},
+		{
+			name:  "zsh",
+			shell: dotenv.Zsh,
+			want: `export PLAIN='value'
+export QUOTES='it'\''s "quoted"'
+export MULTILINE='one
+two'
+export EXPANSION='$HOME $(whoami) ` + "`id`" + ` \n'
+export EMPTY=''
+`,
+		},
+		{
+			name:  "fish",
+			shell: dotenv.Fish,
+			want: `set -gx PLAIN 'value'
+set -gx QUOTES 'it\'s "quoted"'
+set -gx MULTILINE 'one
+two'
+set -gx EXPANSION '$HOME $(whoami) ` + "`id`" + ` \\n'
+set -gx EMPTY ''
+`,
+		},
+		{
+			name:  "powershell",
+			shell: dotenv.PowerShell,
+			want: `$env:PLAIN = 'value'
+$env:QUOTES = 'it''s "quoted"'
+$env:MULTILINE = 'one
+two'
+$env:EXPANSION = '$HOME $(whoami) ` + "`id`" + ` \n'
+$env:EMPTY = ''
+`,
+		},
+		{
+			name:  "nushell",
+			shell: dotenv.Nushell,
+			want: `$env.PLAIN = 'value'
+$env.QUOTES = r#'it's "quoted"'#
+$env.MULTILINE = 'one
+two'
+$env.EXPANSION = '$HOME $(whoami) ` + "`id`" + ` \n'
+$env.EMPTY = ''
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			test.Ok(t, dotenv.NewEncoder(buf, tt.shell).Encode(env))
+			test.Diff(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestEncoderQuoting(t *testing.T) {
+	tests := []struct {
+		name  string       // Name of the test case
+		value string       // Value to encode
+		want  string       // Expected output
+		shell dotenv.Shell // Shell to encode for
+	}{
+		{
+			name:  "fish backslash",
+			shell: dotenv.Fish,
+			value: `C:\path\'`,
+			want:  `set -gx KEY 'C:\\path\\\''` + "\n",
+		},
+		{
+			name:  "powershell typographic quotes",
+			shell: dotenv.PowerShell,
+			value: "it’s",
+			want:  "$env:KEY = 'it’’s'\n",
+		},
+		{
+			name:  "nushell raw string delimiter",
+			shell: dotenv.Nushell,
+			value: "a'# and '##",
+			want:  "$env.KEY = r###'a'# and '##'###\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "KEY='" + strings.ReplaceAll(tt.value, "'", "''") + "'"
+
+			env, err := dotenv.Parse("test.env", []byte(src))
+			test.Ok(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Ok(t, dotenv.NewEncoder(buf, tt.shell).Encode(env))
+			test.Equal(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestEncoderBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	src := `KEY="quotes ' \" backslash \\ dollar \$HOME \$(nope) ` + "`nope`" + ` !! newline \n tab \t end"`
+
+	env, err := dotenv.Parse("test.env", []byte(src), dotenv.In(dotenv.Memory(nil)))
+	test.Ok(t, err)
+
+	buf := &bytes.Buffer{}
+	test.Ok(t, dotenv.NewEncoder(buf, dotenv.Bash).Encode(env))
+
+	// Evaluating the output must set exactly the original value
+	script := buf.String() + `printf '%s' "$KEY"`
+
+	output, err := exec.Command(bash, "--noprofile", "--norc", "-c", script).Output() //nolint:noctx // Test with a short lived command
+	test.Ok(t, err)
+
+	want, _ := env.Get("KEY")
+	test.Equal(t, string(output), want)
+}
+
+func TestEncoderErrors(t *testing.T) {
+	env, err := dotenv.Parse("test.env", []byte("my.key=value\n"), dotenv.DockerEnvFile)
+	test.Ok(t, err)
+
+	buf := &bytes.Buffer{}
+	err = dotenv.NewEncoder(buf, dotenv.Bash).Encode(env)
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `cannot export "my.key", it is not a valid bash variable name`)
+	test.Equal(t, buf.String(), "", test.Context("nothing should be written on error"))
+
+	err = dotenv.NewEncoder(buf, dotenv.Shell(42)).Encode(env)
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "invalid shell: Shell(42)")
+}
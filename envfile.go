@@ -0,0 +1,255 @@
+package dotenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/secret"
+)
+
+// marshalDocker writes env as a 'docker run --env-file' file, with comments on the
+// line above the variable they document.
+//
+// Docker takes everything after the '=' literally so values need no quoting, but they
+// can't span lines.
+func marshalDocker(env Env) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for key, value := range env.All() {
+		if !isName(key) {
+			return nil, fmt.Errorf("%s is not a valid variable name for a docker env file", key)
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("the value of %s spans multiple lines, which docker env files can't represent", key)
+		}
+
+		if comment := env.Comment(key); comment != "" {
+			buf.WriteString("# ")
+			buf.WriteString(comment)
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// marshalSystemd writes env as a systemd EnvironmentFile, with comments on the line
+// above the variable they document.
+func marshalSystemd(env Env) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for key, value := range env.All() {
+		if !isName(key) {
+			return nil, fmt.Errorf("%s is not a valid variable name for a systemd environment file", key)
+		}
+
+		if comment := env.Comment(key); comment != "" {
+			buf.WriteString("# ")
+			buf.WriteString(comment)
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(systemdQuote(value))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// systemdQuote returns value formatted for a systemd EnvironmentFile such that systemd
+// reads back value exactly.
+//
+// Values with no special characters are left bare, anything else is double quoted.
+// Inside double quotes systemd keeps newlines and only decodes \", \\, \$ and \`.
+func systemdQuote(value string) string {
+	if isBare(value) {
+		return value
+	}
+
+	b := &strings.Builder{}
+	b.Grow(len(value) + len(`""`))
+	b.WriteByte('"')
+
+	for i := range len(value) {
+		if strings.IndexByte("\"\\$`", value[i]) != -1 {
+			b.WriteByte('\\')
+		}
+
+		b.WriteByte(value[i])
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// systemd reads a systemd EnvironmentFile, following the rules in systemd.exec(5).
+//
+//   - Blank lines, lines starting with '#' or ';' and lines without an '=' are ignored
+//   - Unquoted values have leading and trailing whitespace removed, a backslash keeps
+//     the next character and at the end of a line continues the value onto the next
+//   - Single quoted values are literal and may span lines
+//   - Double quoted values may span lines, \", \\, \$ and \` are escapes for the
+//     character and a backslash before a newline continues the line
+//   - Quoted and unquoted parts next to each other are joined, with any whitespace
+//     between them removed
+//   - There is no expansion, inline comments or export keyword
+//
+// The comments directly above a variable document it, as a .env inline comment would.
+func (u *unmarshaler) systemd(data []byte) error {
+	r := &systemdReader{src: string(data), line: 1}
+
+	var comments []string // The comment block directly above the current line
+	for r.src != "" {
+		text, rest, _ := strings.Cut(r.src, "\n")
+		trimmed := strings.TrimLeft(text, " \t\r")
+
+		switch {
+		case trimmed == "":
+			comments = comments[:0]
+		case trimmed[0] == '#' || trimmed[0] == ';':
+			comments = append(comments, strings.TrimSpace(trimmed[1:]))
+		case !strings.Contains(trimmed, "="):
+			// Ignored by systemd, which suggests it for commenting
+			comments = comments[:0]
+		default:
+			key, after, _ := strings.Cut(trimmed, "=")
+			key = strings.TrimRight(key, " \t\r")
+			if !isName(key) {
+				return fmt.Errorf("line %d: %q is not a valid variable name", r.line, key)
+			}
+
+			line := r.line
+			r.src = after + "\n" + rest
+
+			value, err := r.value()
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, key, err)
+			}
+
+			comment := strings.Join(comments, " ")
+			u.define([]string{key}, value, comment)
+
+			if secret.Annotated(comment) {
+				conceal(&u.env, key)
+			}
+
+			comments = comments[:0]
+
+			continue
+		}
+
+		r.src = rest
+		r.line++
+	}
+
+	return nil
+}
+
+// systemdReader reads values from a systemd EnvironmentFile.
+type systemdReader struct {
+	src  string // The source still to be read
+	line int    // The line number at the start of src
+}
+
+// next returns the next character of the source, counting lines.
+func (r *systemdReader) next() byte {
+	char := r.src[0]
+	r.src = r.src[1:]
+
+	if char == '\n' {
+		r.line++
+	}
+
+	return char
+}
+
+// value decodes the value at the start of the source, which runs to the end of the
+// line unless quotes or a line continuation carry it further.
+func (r *systemdReader) value() (string, error) {
+	b := &strings.Builder{}
+	end := 0      // Length of b up to the last character that isn't trailing whitespace
+	quote := true // Whether a quote here would begin a quoted part, rather than be literal
+
+	for r.src != "" {
+		switch char := r.next(); {
+		case char == '\n':
+			return b.String()[:end], nil
+		case char == ' ' || char == '\t' || char == '\r':
+			if !quote {
+				b.WriteByte(char)
+			}
+		case char == '\\':
+			quote = false
+
+			if r.src == "" {
+				break
+			}
+
+			if next := r.next(); next != '\n' {
+				b.WriteByte(next)
+				end = b.Len()
+			}
+		case char == '\'' && quote:
+			closing := strings.IndexByte(r.src, '\'')
+			if closing == -1 {
+				return "", errors.New("unterminated single quoted value")
+			}
+
+			b.WriteString(r.src[:closing])
+			r.line += strings.Count(r.src[:closing], "\n")
+			r.src = r.src[closing+1:]
+			end = b.Len()
+		case char == '"' && quote:
+			if err := r.doubleQuoted(b); err != nil {
+				return "", err
+			}
+
+			end = b.Len()
+		default:
+			quote = false
+
+			b.WriteByte(char)
+			end = b.Len()
+		}
+	}
+
+	return b.String()[:end], nil
+}
+
+// doubleQuoted decodes the double quoted part at the start of the source, just after
+// the opening quote, into b.
+func (r *systemdReader) doubleQuoted(b *strings.Builder) error {
+	for r.src != "" {
+		switch char := r.next(); char {
+		case '"':
+			return nil
+		case '\\':
+			if r.src == "" {
+				break
+			}
+
+			switch next := r.next(); next {
+			case '"', '\\', '$', '`':
+				b.WriteByte(next)
+			case '\n':
+				// A line continuation
+			default:
+				b.WriteByte(char)
+				b.WriteByte(next)
+			}
+		default:
+			b.WriteByte(char)
+		}
+	}
+
+	return errors.New("unterminated double quoted value")
+}
@@ -0,0 +1,219 @@
+package dotenv
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Environment is a set of environment variables, such as the process environment.
+//
+// Parsing, loading, interpolation and command substitution all go through an Environment,
+// by default the process environment (see [OS]). Passing a different one with the [In]
+// option keeps them from touching process state, so that e.g. parallel tests can each
+// load a .env file into their own isolated [Memory] environment.
+//
+// Implementations must be safe for concurrent use.
+type Environment interface {
+	// Lookup returns the value of the variable key, and reports whether it is set.
+	Lookup(key string) (string, bool)
+
+	// Environ returns the variables in the form "KEY=value", as [os.Environ] does.
+	Environ() []string
+
+	// Set sets the value of the variable key.
+	Set(key, value string) error
+
+	// Unset removes the variable key.
+	Unset(key string) error
+}
+
+// OS returns the [Environment] of the current process, backed by [os.LookupEnv],
+// [os.Setenv] and friends.
+func OS() Environment {
+	return osEnvironment{}
+}
+
+// Memory returns an in-memory [Environment] initially holding a copy of values, which
+// may be nil.
+//
+// It is isolated from the process environment, so a command substitution run in it
+// sees only its variables. Layer it over [OS] with [Layered] to inherit the rest.
+func Memory(values map[string]string) Environment {
+	values = maps.Clone(values)
+	if values == nil {
+		values = make(map[string]string)
+	}
+
+	return &memory{values: values}
+}
+
+// Layered returns an [Environment] made of layers, the first taking precedence.
+//
+// A variable is looked up in each layer in turn, and Set and Unset change only the
+// first layer so the others are never modified. Unsetting a variable hides it in
+// the lower layers too.
+//
+//	// Changes stay in memory, everything else comes from the process environment
+//	env := dotenv.Layered(dotenv.Memory(nil), dotenv.OS())
+func Layered(layers ...Environment) Environment {
+	if len(layers) == 0 {
+		layers = []Environment{Memory(nil)}
+	}
+
+	return &layered{layers: slices.Clone(layers), unset: make(map[string]bool)}
+}
+
+// osEnvironment is the [Environment] of the current process.
+type osEnvironment struct{}
+
+// Lookup implements [Environment] for the process environment.
+func (osEnvironment) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// Environ implements [Environment] for the process environment.
+func (osEnvironment) Environ() []string {
+	return os.Environ()
+}
+
+// Set implements [Environment] for the process environment.
+func (osEnvironment) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+// Unset implements [Environment] for the process environment.
+func (osEnvironment) Unset(key string) error {
+	return os.Unsetenv(key)
+}
+
+// memory is an in-memory [Environment].
+type memory struct {
+	values map[string]string // The variables, guarded by mu
+	mu     sync.RWMutex      // Guards values
+}
+
+// Lookup implements [Environment] for an in-memory environment.
+func (m *memory) Lookup(key string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.values[key]
+
+	return value, ok
+}
+
+// Environ implements [Environment] for an in-memory environment, the variables are
+// sorted by name.
+func (m *memory) Environ() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return environ(m.values)
+}
+
+// Set implements [Environment] for an in-memory environment.
+func (m *memory) Set(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = value
+
+	return nil
+}
+
+// Unset implements [Environment] for an in-memory environment.
+func (m *memory) Unset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, key)
+
+	return nil
+}
+
+// layered is an [Environment] made of layers, the first taking precedence.
+type layered struct {
+	unset  map[string]bool // Variables unset in the top layer that must be hidden in the lower ones, guarded by mu
+	layers []Environment   // The layers, the first taking precedence
+	mu     sync.RWMutex    // Guards unset
+}
+
+// Lookup implements [Environment] for a layered environment.
+func (l *layered) Lookup(key string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.unset[key] {
+		return "", false
+	}
+
+	for _, layer := range l.layers {
+		if value, ok := layer.Lookup(key); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// Environ implements [Environment] for a layered environment, the variables are
+// sorted by name.
+func (l *layered) Environ() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	values := make(map[string]string)
+	for _, layer := range slices.Backward(l.layers) {
+		for _, variable := range layer.Environ() {
+			key, value, _ := strings.Cut(variable, "=")
+			values[key] = value
+		}
+	}
+
+	for key := range l.unset {
+		delete(values, key)
+	}
+
+	return environ(values)
+}
+
+// Set implements [Environment] for a layered environment.
+func (l *layered) Set(key, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.layers[0].Set(key, value); err != nil {
+		return err
+	}
+
+	delete(l.unset, key)
+
+	return nil
+}
+
+// Unset implements [Environment] for a layered environment.
+func (l *layered) Unset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.layers[0].Unset(key); err != nil {
+		return err
+	}
+
+	l.unset[key] = true
+
+	return nil
+}
+
+// environ returns values in the form "KEY=value", sorted by name.
+func environ(values map[string]string) []string {
+	variables := make([]string, 0, len(values))
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		variables = append(variables, key+"="+values[key])
+	}
+
+	return variables
+}
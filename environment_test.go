@@ -0,0 +1,138 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestMemory(t *testing.T) {
+	initial := map[string]string{"B": "2", "A": "1"}
+	env := dotenv.Memory(initial)
+
+	test.Ok(t, env.Set("C", "3"))
+	test.Ok(t, env.Unset("B"))
+
+	_, ok := initial["C"]
+	test.False(t, ok, test.Context("the initial values are copied"))
+
+	value, ok := env.Lookup("A")
+	test.True(t, ok)
+	test.Equal(t, value, "1")
+
+	_, ok = env.Lookup("B")
+	test.False(t, ok)
+
+	test.EqualFunc(t, env.Environ(), []string{"A=1", "C=3"}, slices.Equal)
+}
+
+func TestLayered(t *testing.T) {
+	top := dotenv.Memory(map[string]string{"A": "top"})
+	bottom := dotenv.Memory(map[string]string{"A": "bottom", "B": "bottom"})
+
+	env := dotenv.Layered(top, bottom)
+
+	value, _ := env.Lookup("A")
+	test.Equal(t, value, "top", test.Context("the first layer takes precedence"))
+
+	value, _ = env.Lookup("B")
+	test.Equal(t, value, "bottom")
+
+	test.EqualFunc(t, env.Environ(), []string{"A=top", "B=bottom"}, slices.Equal)
+
+	test.Ok(t, env.Unset("B"))
+
+	_, ok := env.Lookup("B")
+	test.False(t, ok, test.Context("unsetting hides the lower layers"))
+
+	value, _ = bottom.Lookup("B")
+	test.Equal(t, value, "bottom", test.Context("the lower layers are never modified"))
+
+	test.EqualFunc(t, env.Environ(), []string{"A=top"}, slices.Equal)
+
+	test.Ok(t, env.Set("B", "again"))
+
+	value, _ = env.Lookup("B")
+	test.Equal(t, value, "again")
+
+	value, _ = top.Lookup("B")
+	test.Equal(t, value, "again", test.Context("Set changes the first layer"))
+}
+
+func TestOS(t *testing.T) {
+	t.Setenv("DOTENV_TEST_OS", "yes")
+
+	env := dotenv.OS()
+
+	value, ok := env.Lookup("DOTENV_TEST_OS")
+	test.True(t, ok)
+	test.Equal(t, value, "yes")
+	test.True(t, slices.Contains(env.Environ(), "DOTENV_TEST_OS=yes"))
+
+	test.Ok(t, env.Set("DOTENV_TEST_OS", "changed"))
+	test.Equal(t, os.Getenv("DOTENV_TEST_OS"), "changed")
+
+	test.Ok(t, env.Unset("DOTENV_TEST_OS"))
+
+	_, ok = os.LookupEnv("DOTENV_TEST_OS")
+	test.False(t, ok)
+}
+
+func TestIn(t *testing.T) {
+	t.Parallel()
+
+	t.Run("load", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), ".env")
+		test.Ok(t, os.WriteFile(path, []byte("GREETING=\"hello $WHO\"\nEXISTING=new\n"), 0o600))
+
+		env := dotenv.Memory(map[string]string{"WHO": "world", "EXISTING": "old"})
+		test.Ok(t, dotenv.Load(dotenv.File(path), dotenv.In(env)))
+
+		greeting, _ := env.Lookup("GREETING")
+		test.Equal(t, greeting, "hello world")
+
+		existing, _ := env.Lookup("EXISTING")
+		test.Equal(t, existing, "old", test.Context("existing variables are left alone"))
+
+		_, ok := os.LookupEnv("GREETING")
+		test.False(t, ok, test.Context("the process environment must not be touched"))
+	})
+
+	t.Run("command substitution", func(t *testing.T) {
+		t.Parallel()
+
+		env := dotenv.Layered(dotenv.Memory(map[string]string{"DOTENV_TEST_IN": "isolated"}), dotenv.OS())
+
+		got, err := dotenv.Parse("in.env", []byte("A=$(echo $DOTENV_TEST_IN)\n"), dotenv.In(env))
+		test.Ok(t, err)
+
+		a, _ := got.Get("A")
+		test.Equal(t, a, "isolated")
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		t.Parallel()
+
+		env := dotenv.Memory(map[string]string{"FROM_ENV": "here"})
+
+		got, err := dotenv.Parse("in.env", []byte("FROM_ENV\n"), dotenv.DockerEnvFile, dotenv.In(env))
+		test.Ok(t, err)
+
+		value, _ := got.Get("FROM_ENV")
+		test.Equal(t, value, "here")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := dotenv.Parse("in.env", nil, dotenv.In(nil))
+		test.Err(t, err)
+		test.Equal(t, err.Error(), "cannot use a nil Environment")
+	})
+}
@@ -0,0 +1,477 @@
+package dotenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/expand"
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/token"
+)
+
+// continuations removes line continuations from unquoted values, joining the lines.
+var continuations = strings.NewReplacer("\\\r\n", "", "\\\n", "")
+
+// pythonSingleQuoted decodes the escape sequences supported in python-dotenv's
+// single quoted values.
+var pythonSingleQuoted = strings.NewReplacer(`\\`, `\`, `\'`, `'`)
+
+// evaluator resolves the values of the assignments in a parsed .env file.
+type evaluator struct {
+	decrypter    Decrypter                  // Decrypts encrypted values, may be nil
+	resolvers    map[string]Resolver        // Resolve references to values, by URL scheme
+	environment  Environment                // Where variables not defined in the file are looked up
+	values       map[*ast.Assignment]string // The value of each assignment
+	encrypted    map[*ast.Assignment]bool   // Whether each assignment's value was decrypted
+	secrets      map[*ast.Assignment]bool   // Whether each assignment is a secret
+	name         string                     // Name of the file being evaluated
+	src          []byte                     // Raw source text, for error positions
+	secretValues []string                   // The values of secrets seen so far, to redact from errors
+	file         ast.File                   // The parsed file
+	env          Env                        // The variables resolved so far
+	classifier   secret.Classifier          // Decides which variables are secret
+	dialect      Dialect                    // The dialect of .env syntax
+	resolved     bool                       // Whether the current value used a resolver
+	literal      bool                       // Whether values are being taken as written, without expanding them
+	expanded     bool                       // Whether the current value needed expanding, when literal
+}
+
+// evaluate evaluates every assignment in the file, in order.
+func (e *evaluator) evaluate() error {
+	e.values = make(map[*ast.Assignment]string)
+	e.encrypted = make(map[*ast.Assignment]bool)
+
+	for _, assignment := range e.file.Assignments() {
+		if assignment.Passthrough() {
+			if value, ok := e.environment.Lookup(assignment.Key.Text); ok {
+				e.define(assignment, value)
+			}
+
+			continue
+		}
+
+		if e.literal {
+			value, err := e.asWritten(assignment)
+			if err != nil {
+				return err
+			}
+
+			e.define(assignment, value)
+
+			continue
+		}
+
+		e.resolved = false
+
+		value, err := e.resolvedValue(assignment)
+		if err != nil {
+			return e.redact(assignment, err)
+		}
+
+		if e.resolved {
+			e.secrets[assignment] = true
+		}
+
+		e.define(assignment, value)
+	}
+
+	return nil
+}
+
+// define records the evaluated value of an assignment.
+func (e *evaluator) define(assignment *ast.Assignment, value string) {
+	key := assignment.Key.Text
+
+	e.values[assignment] = value
+	set(&e.env, key, value)
+
+	start := assignment.Key.Start
+	if len(assignment.Value) != 0 {
+		start = assignment.ValuePos()
+	}
+
+	locate(&e.env, key, syntax.Locate(e.name, e.src, start, assignment.End()))
+
+	if assignment.Comment != nil {
+		document(&e.env, key, assignment.Comment.Token.Value())
+	}
+
+	if e.secrets[assignment] {
+		conceal(&e.env, key)
+		e.secretValues = append(e.secretValues, value)
+	}
+}
+
+// redact removes the values of any secrets from err, which occurred while evaluating
+// assignment.
+//
+// If assignment is itself a secret, its source text is redacted too as the error may
+// have been caused by and so quote part of its value. The names of the variables and
+// commands it expands are not secret, and are left in so the error still makes sense.
+func (e *evaluator) redact(assignment *ast.Assignment, err error) error {
+	var syntaxErr syntax.Error
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	values := e.secretValues
+	if e.secrets[assignment] && len(assignment.Value) != 0 {
+		values = slices.Concat(values, []string{string(e.src[assignment.ValuePos():assignment.End()])})
+		for _, part := range assignment.Value {
+			if part.Is(token.VarInterp, token.CmdInterp) {
+				continue
+			}
+
+			values = append(values, part.Text, part.Value())
+		}
+	}
+
+	syntaxErr.Msg = secret.Redact(syntaxErr.Msg, values...)
+
+	return syntaxErr
+}
+
+// resolvedValue evaluates the value of an assignment, resolving it if it's a reference in
+// its entirety and decrypting it if it's encrypted.
+func (e *evaluator) resolvedValue(assignment *ast.Assignment) (string, error) {
+	if ref, ok := e.wholeReference(assignment); ok {
+		return e.resolveValue(assignment, ref)
+	}
+
+	value, err := e.value(assignment)
+	if err != nil {
+		return "", err
+	}
+
+	if e.decrypter != nil && IsEncrypted(value) {
+		value, err = e.decrypt(assignment, value)
+		if err != nil {
+			return "", err
+		}
+
+		e.encrypted[assignment] = true
+	}
+
+	return value, nil
+}
+
+// wholeReference returns the reference an assignment's value is, if it's written as a
+// bare unquoted URL with a scheme that has a resolver.
+//
+// Only the source text counts, so a quoted value or one built by interpolation is never
+// resolved, however much it looks like a reference once evaluated.
+func (e *evaluator) wholeReference(assignment *ast.Assignment) (string, bool) {
+	if len(assignment.Value) == 0 || len(e.resolvers) == 0 {
+		return "", false
+	}
+
+	for _, part := range assignment.Value {
+		if !part.Is(token.Ident, token.String, token.Eq) || quotes(part) != "" || strings.Contains(part.Text, `\`) {
+			return "", false
+		}
+	}
+
+	ref := string(e.src[assignment.ValuePos():assignment.End()])
+	if !isReference(e.resolvers, ref) {
+		return "", false
+	}
+
+	return ref, true
+}
+
+// decrypt decrypts the encrypted value of an assignment.
+func (e *evaluator) decrypt(assignment *ast.Assignment, value string) (string, error) {
+	decrypted, err := e.decrypter.Decrypt(assignment.Key.Text, value)
+	if err != nil {
+		return "", syntax.Error{
+			Pos: syntax.Locate(e.name, e.src, assignment.ValuePos(), assignment.End()),
+			Msg: err.Error(),
+		}
+	}
+
+	return decrypted, nil
+}
+
+// resolveValue resolves the value of an assignment that is a reference in its entirety.
+func (e *evaluator) resolveValue(assignment *ast.Assignment, ref string) (string, error) {
+	value, err := e.reference(ref)
+	if err != nil {
+		return "", syntax.Error{
+			Pos: syntax.Locate(e.name, e.src, assignment.ValuePos(), assignment.End()),
+			Msg: err.Error(),
+		}
+	}
+
+	return value, nil
+}
+
+// reference resolves a reference, remembering that the current value used one.
+func (e *evaluator) reference(ref string) (string, error) {
+	value, err := resolveReference(e.resolvers, ref)
+	if err != nil {
+		return "", err
+	}
+
+	e.resolved = true
+	e.secretValues = append(e.secretValues, value)
+
+	return value, nil
+}
+
+// asWritten returns the value of an assignment for the [Literal] option, its literal
+// value if it has one or otherwise its source text.
+func (e *evaluator) asWritten(assignment *ast.Assignment) (string, error) {
+	value, ok, err := e.literalValue(assignment)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return string(e.src[assignment.ValuePos():assignment.End()]), nil
+	}
+
+	return value, nil
+}
+
+// literalValue returns the value of an assignment as it is written, with any quotes
+// removed and escape sequences decoded but nothing expanded, for which e must be literal.
+// It reports false if the value uses interpolation or command substitution, and so has
+// no literal value.
+func (e *evaluator) literalValue(assignment *ast.Assignment) (string, bool, error) {
+	e.expanded = false
+
+	value, err := e.value(assignment)
+	if err != nil {
+		return "", false, e.redact(assignment, err)
+	}
+
+	return value, !e.expanded, nil
+}
+
+// value evaluates the value of a single assignment.
+func (e *evaluator) value(assignment *ast.Assignment) (string, error) {
+	value := &strings.Builder{}
+
+	for _, part := range assignment.Value {
+		evaluated, err := e.part(part)
+		if err != nil {
+			return "", err
+		}
+
+		value.WriteString(evaluated)
+	}
+
+	return value.String(), nil
+}
+
+// part evaluates a single token forming part of a value.
+func (e *evaluator) part(tok token.Token) (string, error) {
+	if e.dialect == DockerEnvFile {
+		// Docker takes everything literally
+		return tok.Text, nil
+	}
+
+	switch tok.Kind {
+	case token.RawString:
+		if e.dialect == PythonDotenv {
+			return pythonSingleQuoted.Replace(tok.Value()), nil
+		}
+
+		return tok.Value(), nil
+	case token.String:
+		if quote := quotes(tok); quote != "" {
+			content := strings.TrimSuffix(strings.TrimPrefix(tok.Text, quote), quote)
+			if quote == `"""` {
+				// Dedenting moves things around so errors can only point to the string
+				return e.expand(tok, token.Dedent(content), -1, true)
+			}
+
+			return e.expand(tok, content, len(quote), true)
+		}
+
+		return e.expand(tok, continuations.Replace(tok.Text), 0, false)
+	default:
+		return e.expand(tok, continuations.Replace(tok.Text), 0, false)
+	}
+}
+
+// expand performs the dialect appropriate expansion of content, which begins offset
+// bytes into tok. Quoted reports whether content was inside double quotes.
+//
+// A negative offset means content does not appear verbatim in tok, so errors are
+// reported at the start of tok.
+func (e *evaluator) expand(tok token.Token, content string, offset int, quoted bool) (string, error) {
+	cfg := expand.Config{
+		Lookup:    e.lookup,
+		Reference: e.reference,
+	}
+
+	switch e.dialect {
+	case Strict:
+		cfg.Command = e.command
+		cfg.Strict = true
+		if quoted {
+			cfg.Escape = token.Escape
+		}
+	case Compose:
+		cfg.DollarEscape = true
+		if quoted {
+			cfg.Escape = token.Escape
+		}
+	case PythonDotenv:
+		cfg.BracedOnly = true
+		if quoted {
+			cfg.Escape = pythonEscape
+		}
+	case NodeDotenv:
+		if quoted {
+			content = strings.ReplaceAll(content, `\n`, "\n")
+			content = strings.ReplaceAll(content, `\r`, "\r")
+		}
+
+		return content, nil
+	case POSIXShell:
+		cfg.Command = e.command
+		if quoted {
+			cfg.Escape = posixQuotedEscape
+		} else {
+			cfg.Escape = posixEscape
+		}
+	default:
+		// Docker takes everything literally
+		return content, nil
+	}
+
+	if e.literal {
+		// Only whether anything would be expanded matters, nothing is run or looked up
+		cfg.Lookup = func(name string) (string, bool) {
+			e.expanded = true
+			return name, true // Non-empty, so ${VAR:?message} doesn't fail
+		}
+		cfg.Reference = func(string) (string, error) {
+			e.expanded = true
+			return "", nil
+		}
+		if cfg.Command != nil {
+			cfg.Command = func(string) (string, error) {
+				e.expanded = true
+				return "", nil
+			}
+		}
+	}
+
+	expanded, err := expand.Expand(content, cfg)
+	if err != nil {
+		start, _ := tok.Span()
+		var expandErr expand.Error
+		if errors.As(err, &expandErr) && offset >= 0 {
+			start += offset + expandErr.Offset
+		}
+
+		return "", syntax.Error{
+			Pos: syntax.Locate(e.name, e.src, start, start+1),
+			Msg: err.Error(),
+		}
+	}
+
+	return expanded, nil
+}
+
+// lookup looks up a variable for interpolation, first in the variables defined so
+// far and then in the environment.
+func (e *evaluator) lookup(name string) (string, bool) {
+	if value, ok := e.env.Get(name); ok {
+		return value, true
+	}
+
+	value, ok := e.environment.Lookup(name)
+	if ok && e.classifier.Key(name) {
+		// Looks like a secret from the environment, keep it out of any errors
+		e.secretValues = append(e.secretValues, value)
+	}
+
+	return value, ok
+}
+
+// command runs cmd in a shell for command substitution, returning its output.
+//
+// The variables defined so far are made available to the command's environment.
+func (e *evaluator) command(cmd string) (string, error) {
+	command := exec.Command("sh", "-c", cmd) //nolint:noctx // Running the user's command is the point
+	command.Env = e.environment.Environ()
+
+	for key, value := range e.env.All() {
+		command.Env = append(command.Env, key+"="+value)
+	}
+
+	stderr := &bytes.Buffer{}
+	command.Stderr = stderr
+
+	output, err := command.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// quotes returns the quotes surrounding a string token, or "" if it is unquoted.
+func quotes(tok token.Token) string {
+	switch {
+	case strings.HasPrefix(tok.Text, `"""`) && len(tok.Text) >= 6:
+		return `"""`
+	case strings.HasPrefix(tok.Text, `"`):
+		return `"`
+	default:
+		return ""
+	}
+}
+
+// pythonEscape decodes the escape sequences supported in python-dotenv's
+// double quoted values.
+func pythonEscape(char byte) (byte, bool) {
+	switch char {
+	case 'a':
+		return '\a', true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'v':
+		return '\v', true
+	case '\'':
+		return char, true
+	case '$':
+		// Python doesn't treat "\$" specially
+		return 0, false
+	default:
+		return token.Escape(char)
+	}
+}
+
+// posixQuotedEscape decodes the escape sequences permitted inside double quotes
+// in a POSIX shell.
+func posixQuotedEscape(char byte) (byte, bool) {
+	switch char {
+	case '$', '`', '"', '\\':
+		return char, true
+	default:
+		return 0, false
+	}
+}
+
+// posixEscape decodes escape sequences in unquoted POSIX shell words, where a
+// backslash escapes any character.
+func posixEscape(char byte) (byte, bool) {
+	return char, true
+}
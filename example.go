@@ -0,0 +1,171 @@
+package dotenv
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+)
+
+// ExampleFile is the conventional name of the example file documenting the
+// variables in a .env file, see [Example].
+const ExampleFile = ".env.example"
+
+// Example generates the source of an example file (e.g. .env.example) from the
+// .env source src, suitable for committing alongside the code.
+//
+// Keys, comments and formatting are kept exactly as they are, only values change.
+// Values that are safe defaults (bools, ints and durations) are kept, everything else
+// is replaced by the placeholder (see [Placeholder]) which is empty by default.
+// Secrets (see [SecretKeys]) are always replaced, however innocent they look.
+//
+// Like [EncryptFile], values are taken as written: nothing is expanded and no commands
+// are run, so values using interpolation or command substitution are replaced too.
+func Example(name string, src []byte, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.literal = true
+
+	ev, err := newEvaluator(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholder := ""
+	if cfg.placeholder != "" {
+		placeholder = quote(cfg.placeholder)
+	}
+
+	return rewrite(src, ev.file, func(assignment *ast.Assignment) (string, bool, error) {
+		value, literal, err := ev.literalValue(assignment)
+		if err != nil {
+			return "", false, err
+		}
+
+		if literal && !ev.secrets[assignment] && safe(value) {
+			return "", false, nil
+		}
+
+		return placeholder, true, nil
+	})
+}
+
+// MergeExample merges a template generated by [Example] into the existing example
+// file source example, returning the new source of the example file.
+//
+// The variables in template that example doesn't already declare are appended, each
+// with the comments directly above it. The rest of example, including any hand-written
+// documentation and the values of existing variables, is left exactly as it was.
+//
+// The name is used in error messages and is typically the name of the example file.
+func MergeExample(name string, example, template []byte, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := parseFile(name, example, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := parseFile("template", template, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	for _, assignment := range existing.Assignments() {
+		declared[assignment.Key.Text] = true
+	}
+
+	out := bytes.NewBuffer(bytes.Clone(example))
+	appended := false
+
+	start := -1 // Start of the block of comments directly above the current statement, -1 if none
+	for _, statement := range generated.Statements {
+		switch node := statement.(type) {
+		case *ast.Comment:
+			if start == -1 {
+				start = node.Pos()
+			}
+		case *ast.Assignment:
+			if !declared[node.Key.Text] {
+				from := node.Pos()
+				if node.Exported() {
+					from = node.Export.Start
+				}
+
+				block := template[from:lineEnd(template, node)]
+				if start != -1 {
+					block = template[start:lineEnd(template, node)]
+				}
+
+				separate(out, !appended || start != -1)
+				out.Write(block)
+
+				appended = true
+				declared[node.Key.Text] = true
+			}
+
+			start = -1
+		default:
+			start = -1
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// safe reports whether value is safe to keep as the default in an example file.
+func safe(value string) bool {
+	if _, err := strconv.ParseBool(value); err == nil {
+		return true
+	}
+
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return true
+	}
+
+	if _, err := time.ParseDuration(value); err == nil {
+		return true
+	}
+
+	return false
+}
+
+// lineEnd returns the offset in src just past the end of the line on which assignment
+// ends, including any inline comment and the newline.
+func lineEnd(src []byte, assignment *ast.Assignment) int {
+	end := assignment.End()
+	if assignment.Comment != nil {
+		end = assignment.Comment.End()
+	}
+
+	newline := bytes.IndexByte(src[end:], '\n')
+	if newline == -1 {
+		return len(src)
+	}
+
+	return end + newline + 1
+}
+
+// separate prepares out for another block of statements to be appended, making
+// sure it ends in a newline and, if blank is true, a blank line.
+func separate(out *bytes.Buffer, blank bool) {
+	if out.Len() == 0 {
+		return
+	}
+
+	if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	if blank && !bytes.HasSuffix(out.Bytes(), []byte("\n\n")) {
+		out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,29 @@
+// Code generated by "stringer -type Format -linecomment"; DO NOT EDIT.
+
+package dotenv
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[FormatDotenv-0]
+	_ = x[FormatJSON-1]
+	_ = x[FormatYAML-2]
+	_ = x[FormatTOML-3]
+	_ = x[FormatDocker-4]
+	_ = x[FormatSystemd-5]
+}
+
+const _Format_name = "dotenvjsonyamltomldockersystemd"
+
+var _Format_index = [...]uint8{0, 6, 10, 14, 18, 24, 31}
+
+func (i Format) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Format_index)-1 {
+		return "Format(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Format_name[_Format_index[idx]:_Format_index[idx+1]]
+}
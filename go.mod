@@ -3,11 +3,16 @@ module go.followtheprocess.codes/dotenv
 go 1.24
 
 require (
+	filippo.io/age v1.2.1
+	github.com/BurntSushi/toml v1.6.0
 	go.followtheprocess.codes/hue v0.6.0
 	go.followtheprocess.codes/test v0.22.0
+	golang.org/x/sys v0.35.0
+	golang.org/x/tools v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	golang.org/x/sys v0.34.0 // indirect
-	golang.org/x/term v0.33.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/term v0.34.0 // indirect
 )
@@ -0,0 +1,193 @@
+// Package cli implements the dotenv command line interface.
+//
+// Each subcommand is a function taking its arguments and the standard streams, with
+// flags parsed by the standard library's flag package so that the package has no
+// dependencies beyond dotenv itself.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/parser"
+)
+
+// Streams holds the standard streams a command reads from and writes to.
+type Streams struct {
+	Stdin  io.Reader // Where to read input from
+	Stdout io.Writer // Where to write normal output
+	Stderr io.Writer // Where to write diagnostics and usage
+}
+
+// command is a single dotenv subcommand.
+type command struct {
+	run   func(args []string, streams Streams) error // Run the command with its arguments
+	name  string                                     // Name of the command, as typed by the user
+	short string                                     // One line description, shown in the overall usage
+}
+
+// commands returns all the dotenv subcommands, in the order they are listed in the usage.
+func commands() []command {
+	return []command{
+		{name: "encrypt", short: "Encrypt the values in a .env file", run: encrypt},
+		{name: "decrypt", short: "Decrypt the values in an encrypted .env file", run: decrypt},
+		{name: "keygen", short: "Generate a new encryption key", run: keygen},
+		{name: "validate", short: "Validate a .env file against a schema", run: validate},
+		{name: "diff", short: "Compare two .env files e.g. .env and .env.example", run: diff},
+		{name: "example", short: "Generate a .env.example from a .env file", run: example},
+		{name: "export", short: "Print shell commands setting the variables in a .env file", run: export},
+		{name: "convert", short: "Convert variables between .env, JSON, YAML, TOML, docker and systemd", run: convert},
+		{name: "kube", short: "Generate a Kubernetes ConfigMap and Secret from a .env file", run: kube},
+		{name: "template", short: "Replace references to variables in a template file", run: template},
+		{name: "gen", short: "Generate a Go struct from a .env.example", run: generate},
+	}
+}
+
+// Run runs the dotenv command line with args, not including the program name.
+func Run(args []string, streams Streams) error {
+	if len(args) == 0 {
+		usage(streams.Stderr)
+		return errors.New("no command given")
+	}
+
+	name, rest := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(streams.Stdout)
+		return nil
+	}
+
+	index := slices.IndexFunc(commands(), func(cmd command) bool { return cmd.name == name })
+	if index == -1 {
+		usage(streams.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	err := commands()[index].run(rest, streams)
+	if errors.Is(err, flag.ErrHelp) {
+		// The usage has already been shown
+		return nil
+	}
+
+	return err
+}
+
+// exitError is an error that should cause the process to exit with a particular code.
+type exitError struct {
+	err  error // The underlying error
+	code int   // The exit code
+}
+
+// Error implements the error interface for an [exitError].
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the code the process should exit with after [Run] returned err.
+//
+// This is 0 if err is nil and 1 for most errors, but some commands use other codes
+// e.g. diff exits with 1 if the files differ and 2 if it couldn't compare them.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exit exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+
+	return 1
+}
+
+// Report writes err, returned by [Run], to w for the user to read.
+//
+// Syntax errors in files are shown along with the source around them, with the values
+// of any secrets in the file masked. Anything else is written on a line of its own.
+func Report(w io.Writer, err error) {
+	var exit exitError
+	if errors.As(err, &exit) {
+		err = exit.err
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		var syntaxErr syntax.Error
+		if !errors.As(err, &syntaxErr) {
+			fmt.Fprintf(w, "dotenv: %v\n", err)
+			continue
+		}
+
+		src, readErr := os.ReadFile(syntaxErr.Pos.Name)
+		if readErr != nil {
+			// Not a file e.g. stdin, so there's no context to show
+			fmt.Fprintf(w, "dotenv: %v\n", err)
+			continue
+		}
+
+		syntax.PrettyConsoleHandler(w, secretSpans(syntaxErr.Pos.Name, src)...)(syntaxErr.Pos, syntaxErr.Msg)
+	}
+}
+
+// secretSpans returns the spans of the values of the secrets in the .env source src.
+//
+// The source is parsed a line at a time as well as normally, so that a secret is still
+// masked on a line the normal parse rejected, which is the line the error is shown on.
+func secretSpans(name string, src []byte) []syntax.Span {
+	classifier := secret.New()
+
+	// What could be parsed is enough, the errors are the ones being reported
+	file, _ := parser.New(name, src, nil).Parse()                           //nolint:errcheck // See above
+	lines, _ := parser.New(name, src, nil, parser.WithLineValues()).Parse() //nolint:errcheck // See above
+
+	return slices.Concat(secret.Spans(classifier.File(file)), secret.Spans(classifier.File(lines)))
+}
+
+// usage writes the overall usage of the dotenv command to w.
+func usage(w io.Writer) {
+	b := &strings.Builder{}
+	b.WriteString("Usage: dotenv <command> [flags] [args]\n\nCommands:\n")
+
+	for _, cmd := range commands() {
+		fmt.Fprintf(b, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+
+	b.WriteString("\nRun 'dotenv <command> -h' for help with a command.\n")
+
+	fmt.Fprint(w, b.String())
+}
+
+// newFlagSet returns a flag set for a command, with usage showing synopsis and
+// description followed by the command's flags.
+func newFlagSet(name, synopsis, description string, streams Streams) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(streams.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: dotenv %s %s\n\n%s\n", name, synopsis, description)
+
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+
+		if hasFlags {
+			fmt.Fprint(flags.Output(), "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
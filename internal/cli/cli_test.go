@@ -0,0 +1,433 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/internal/cli"
+	"go.followtheprocess.codes/test"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string   // Name of the test case
+		wantErr    string   // Expected error, if any
+		wantStdout string   // Expected substring of stdout
+		wantStderr string   // Expected substring of stderr
+		args       []string // Arguments to run with
+	}{
+		{name: "no args", args: nil, wantErr: "no command given", wantStderr: "Usage: dotenv <command>"},
+		{name: "help", args: []string{"help"}, wantStdout: "encrypt    Encrypt the values in a .env file"},
+		{name: "unknown", args: []string{"nope"}, wantErr: `unknown command "nope"`, wantStderr: "Commands:"},
+		{name: "command help", args: []string{"encrypt", "-h"}, wantStderr: "Usage: dotenv encrypt [flags] <file>"},
+		{name: "bad flag", args: []string{"keygen", "-nope"}, wantErr: "flag provided but not defined: -nope"},
+		{name: "missing file", args: []string{"decrypt"}, wantErr: "decrypt takes exactly 1 file, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			err := cli.Run(tt.args, cli.Streams{Stdout: stdout, Stderr: stderr})
+			if tt.wantErr != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.wantErr)
+			} else {
+				test.Ok(t, err)
+			}
+
+			test.True(t, strings.Contains(stdout.String(), tt.wantStdout), test.Context("stdout: %s", stdout))
+			test.True(t, strings.Contains(stderr.String(), tt.wantStderr), test.Context("stderr: %s", stderr))
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, keygenArgs := range [][]string{{"keygen"}, {"keygen", "-age"}} {
+		t.Run(strings.Join(keygenArgs, " "), func(t *testing.T) {
+			dir := t.TempDir()
+
+			key := &bytes.Buffer{}
+			test.Ok(t, cli.Run(keygenArgs, cli.Streams{Stdout: key, Stderr: &bytes.Buffer{}}))
+
+			keyFile := filepath.Join(dir, "key")
+			test.Ok(t, os.WriteFile(keyFile, key.Bytes(), 0o600))
+
+			src := "# The database password\nDB_PASSWORD=hunter2\n"
+			envFile := filepath.Join(dir, ".env")
+			test.Ok(t, os.WriteFile(envFile, []byte(src), 0o600))
+
+			// Written to stdout, file untouched
+			stdout := &bytes.Buffer{}
+			test.Ok(t, cli.Run([]string{"encrypt", "-key", keyFile, envFile}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+			test.True(
+				t,
+				strings.HasPrefix(stdout.String(), "# The database password\nDB_PASSWORD=ENC["),
+				test.Context("stdout: %s", stdout),
+			)
+
+			contents, err := os.ReadFile(envFile)
+			test.Ok(t, err)
+			test.Equal(t, string(contents), src)
+
+			// In place, with the key from the environment
+			t.Setenv(dotenv.KeyFileVar, keyFile)
+			test.Ok(t, cli.Run([]string{"encrypt", "-w", envFile}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}))
+
+			contents, err = os.ReadFile(envFile)
+			test.Ok(t, err)
+			test.True(t, strings.Contains(string(contents), "DB_PASSWORD=ENC["), test.Context("file: %s", contents))
+
+			stdout.Reset()
+			test.Ok(t, cli.Run([]string{"decrypt", envFile}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+			test.Equal(t, stdout.String(), src)
+		})
+	}
+}
+
+func TestEncryptNoKey(t *testing.T) {
+	t.Setenv(dotenv.KeyVar, "")
+	t.Setenv(dotenv.KeyFileVar, "")
+
+	err := cli.Run([]string{"encrypt", "whatever.env"}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "no encryption key, set $DOTENV_KEY or $DOTENV_KEY_FILE")
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+
+	schema := "# @type int\nPORT=\n\n# @type enum debug info\nLOG_LEVEL=\n"
+	test.Ok(t, os.WriteFile(filepath.Join(dir, ".env.example"), []byte(schema), 0o600))
+
+	good := filepath.Join(dir, "good.env")
+	test.Ok(t, os.WriteFile(good, []byte("PORT=8080\nLOG_LEVEL=info\n"), 0o600))
+
+	bad := filepath.Join(dir, "bad.env")
+	test.Ok(t, os.WriteFile(bad, []byte("PORT=eighty\n"), 0o600))
+
+	schemaFlag := []string{"-schema", filepath.Join(dir, ".env.example")}
+
+	stdout := &bytes.Buffer{}
+	test.Ok(
+		t,
+		cli.Run(slices.Concat([]string{"validate"}, schemaFlag, []string{good}), cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}),
+	)
+	test.Equal(t, stdout.String(), "")
+
+	err := cli.Run(slices.Concat([]string{"validate"}, schemaFlag, []string{bad}), cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), bad+": 2 invalid variable(s)")
+
+	want := bad + `:1:6-12: PORT must be an int, got "eighty"` + "\n" +
+		filepath.Join(dir, ".env.example") + ":5:1-10: LOG_LEVEL is required but not set\n"
+	test.Diff(t, stdout.String(), want)
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+
+	left := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(left, []byte("A=1\nDB_PASSWORD=hunter2\nB=2\n"), 0o600))
+
+	right := filepath.Join(dir, ".env.example")
+	test.Ok(t, os.WriteFile(right, []byte("B=2\nDB_PASSWORD=changeme\nC=3\n"), 0o600))
+
+	tests := []struct {
+		name     string   // Name of the test case
+		want     string   // Expected stdout
+		args     []string // Flags to pass
+		wantCode int      // Expected exit code
+	}{
+		{
+			name:     "keys",
+			want:     right + " is missing A\n" + left + " is missing C\n",
+			wantCode: 1,
+		},
+		{
+			name: "values",
+			args: []string{"-values"},
+			want: right + " is missing A\n" +
+				"DB_PASSWORD differs: [REDACTED] in " + left + ", [REDACTED] in " + right + "\n" +
+				left + " is missing C\n",
+			wantCode: 1,
+		},
+		{
+			name: "reveal",
+			args: []string{"-values", "-reveal"},
+			want: right + " is missing A\n" +
+				`DB_PASSWORD differs: "hunter2" in ` + left + `, "changeme" in ` + right + "\n" +
+				left + " is missing C\n",
+			wantCode: 1,
+		},
+		{
+			name: "order",
+			args: []string{"-order"},
+			want: right + " is missing A\n" +
+				"DB_PASSWORD is in a different position in " + left + " and " + right + "\n" +
+				left + " is missing C\n",
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			err := cli.Run(
+				slices.Concat([]string{"diff"}, tt.args, []string{left, right}),
+				cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}},
+			)
+			test.Equal(t, cli.ExitCode(err), tt.wantCode)
+			test.Diff(t, stdout.String(), tt.want)
+		})
+	}
+
+	t.Run("same", func(t *testing.T) {
+		err := cli.Run([]string{"diff", left, left}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+		test.Ok(t, err)
+		test.Equal(t, cli.ExitCode(err), 0)
+	})
+
+	t.Run("not evaluated", func(t *testing.T) {
+		expanding := filepath.Join(dir, "expanding.env")
+		test.Ok(t, os.WriteFile(expanding, []byte("A=$(exit 1)\nB=${DOTENV_TEST_UNDEFINED}\n"), 0o600))
+
+		other := filepath.Join(dir, "other.env")
+		test.Ok(t, os.WriteFile(other, []byte("A=$(exit 1)\nB=\"${DOTENV_TEST_UNDEFINED}\"\n"), 0o600))
+
+		stdout := &bytes.Buffer{}
+		err := cli.Run([]string{"diff", "-values", expanding, other}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}})
+		test.Equal(t, cli.ExitCode(err), 1)
+
+		want := `B differs: "${DOTENV_TEST_UNDEFINED}" in ` + expanding + `, "\"${DOTENV_TEST_UNDEFINED}\"" in ` + other + "\n"
+		test.Diff(t, stdout.String(), want)
+	})
+
+	t.Run("trouble", func(t *testing.T) {
+		err := cli.Run([]string{"diff", left, filepath.Join(dir, "missing")}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+		test.Err(t, err)
+		test.Equal(t, cli.ExitCode(err), 2)
+	})
+}
+
+func TestExample(t *testing.T) {
+	dir := t.TempDir()
+
+	env := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(env, []byte("PORT=8080\nAPI_TOKEN=abc123\nNEW=value\n"), 0o600))
+
+	stdout := &bytes.Buffer{}
+	test.Ok(t, cli.Run([]string{"example", env}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Equal(t, stdout.String(), "PORT=8080\nAPI_TOKEN=\nNEW=\n")
+
+	example := filepath.Join(dir, ".env.example")
+	test.Ok(t, os.WriteFile(example, []byte("# The port\nPORT=\n# The token\nAPI_TOKEN=xxx\n"), 0o600))
+
+	test.Ok(t, cli.Run([]string{"example", "-o", example, env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}))
+
+	contents, err := os.ReadFile(example)
+	test.Ok(t, err)
+	test.Diff(t, string(contents), "# The port\nPORT=\n# The token\nAPI_TOKEN=xxx\n\nNEW=\n")
+}
+
+func TestGen(t *testing.T) {
+	dir := t.TempDir()
+
+	example := filepath.Join(dir, ".env.example")
+	test.Ok(t, os.WriteFile(example, []byte("PORT=8080\n"), 0o600))
+
+	t.Setenv("GOPACKAGE", "config")
+
+	output := filepath.Join(dir, "config_env.go")
+	test.Ok(t, cli.Run([]string{"gen", "-o", output, example}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}))
+
+	code, err := os.ReadFile(output)
+	test.Ok(t, err)
+	test.True(t, strings.Contains(string(code), "package config\n"), test.Context("code: %s", code))
+	test.True(t, strings.Contains(string(code), "Port int `env:\"PORT\"`"), test.Context("code: %s", code))
+
+	t.Setenv("GOPACKAGE", "")
+
+	err = cli.Run([]string{"gen", example}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "no package name, pass -pkg or run with go generate")
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+
+	env := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(env, []byte("GREETING=\"it's here\"\nPORT=8080\n"), 0o600))
+
+	local := filepath.Join(dir, ".env.local")
+	test.Ok(t, os.WriteFile(local, []byte("PORT=9090\n"), 0o600))
+
+	t.Setenv("SHELL", "/usr/bin/fish")
+
+	stdout := &bytes.Buffer{}
+	test.Ok(t, cli.Run([]string{"export", env, local}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "set -gx GREETING 'it\\'s here'\nset -gx PORT '8080'\nset -gx PORT '9090'\n")
+
+	stdout.Reset()
+	test.Ok(t, cli.Run([]string{"export", "-shell", "bash", env}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "export GREETING='it'\\''s here'\nexport PORT='8080'\n")
+
+	stdout.Reset()
+	test.Ok(t, cli.Run([]string{"export", "-shell", "pwsh", env}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "$env:GREETING = 'it''s here'\n$env:PORT = '8080'\n")
+
+	// An unrecognised $SHELL falls back to bash
+	t.Setenv("SHELL", "/bin/tcsh")
+
+	stdout.Reset()
+	test.Ok(t, cli.Run([]string{"export", env}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "export GREETING='it'\\''s here'\nexport PORT='8080'\n")
+
+	err := cli.Run([]string{"export", "-shell", "tcsh", env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `unknown shell "tcsh", expected bash, zsh, fish, powershell or nushell`)
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+
+	env := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(env, []byte("APP__DB__HOST=localhost # The host\nAPP__PORT=8080\n"), 0o600))
+
+	stdout := &bytes.Buffer{}
+	test.Ok(t, cli.Run([]string{"convert", "-to", "yaml", "-nest", "__", env}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "app:\n  db:\n    host: localhost # The host\n  port: \"8080\"\n")
+
+	// Formats from the file extensions
+	output := filepath.Join(dir, "config.json")
+	test.Ok(t, cli.Run([]string{"convert", "-o", output, env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}))
+
+	contents, err := os.ReadFile(output)
+	test.Ok(t, err)
+	test.Diff(t, string(contents), "{\n  \"APP__DB__HOST\": \"localhost\",\n  \"APP__PORT\": \"8080\"\n}\n")
+
+	// And back from stdin
+	stdout.Reset()
+	streams := cli.Streams{Stdin: bytes.NewReader(contents), Stdout: stdout, Stderr: &bytes.Buffer{}}
+	test.Ok(t, cli.Run([]string{"convert", "-from", "json", "-to", "dotenv", "-"}, streams))
+	test.Diff(t, stdout.String(), "APP__DB__HOST=localhost\nAPP__PORT=8080\n")
+
+	err = cli.Run([]string{"convert", env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "no output format, pass -to or -o with a file extension")
+
+	err = cli.Run([]string{"convert", "-to", "xml", env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `unknown format "xml", expected one of dotenv, json, yaml, toml, docker, systemd`)
+
+	// A systemd EnvironmentFile to .env
+	unit := filepath.Join(dir, "app.conf")
+	test.Ok(t, os.WriteFile(unit, []byte("# The greeting\nGREETING=\"hello world\"\nPATH=/bin:$PATH\n"), 0o600))
+
+	stdout.Reset()
+	test.Ok(
+		t,
+		cli.Run([]string{"convert", "-from", "systemd", "-to", "dotenv", unit}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}),
+	)
+	test.Diff(t, stdout.String(), "GREETING=\"hello world\" # The greeting\nPATH=\"/bin:\\$PATH\"\n")
+}
+
+func TestKube(t *testing.T) {
+	dir := t.TempDir()
+
+	env := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(env, []byte("PORT=8080 # The port\nAPI_TOKEN=hunter2\nDATABASE_URL=postgres://db\n"), 0o600))
+
+	stdout := &bytes.Buffer{}
+	args := []string{"kube", "-name", "app", "-namespace", "prod", "-label", "app=web", "-label", "tier=api", "-secret", "DATABASE_*", env}
+	test.Ok(t, cli.Run(args, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+
+	want := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: web
+    tier: api
+data:
+  PORT: "8080" # The port
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: web
+    tier: api
+type: Opaque
+data:
+  API_TOKEN: aHVudGVyMg==
+  DATABASE_URL: cG9zdGdyZXM6Ly9kYg==
+`
+	test.Diff(t, stdout.String(), want)
+
+	err := cli.Run([]string{"kube", env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "a Kubernetes manifest needs a name")
+
+	err = cli.Run([]string{"kube", "-name", "app", "-label", "nope", env}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	env := filepath.Join(dir, ".env")
+	test.Ok(t, os.WriteFile(env, []byte("DOTENV_TEST_PORT=8080\n"), 0o600))
+
+	tmpl := filepath.Join(dir, "nginx.conf.tmpl")
+	test.Ok(t, os.WriteFile(tmpl, []byte("listen ${DOTENV_TEST_PORT};\nreturn 301 $scheme://${DOTENV_TEST_HOST:-localhost};\n"), 0o600))
+
+	stdout := &bytes.Buffer{}
+	test.Ok(t, cli.Run([]string{"template", "-env", env, tmpl}, cli.Streams{Stdout: stdout, Stderr: &bytes.Buffer{}}))
+	test.Diff(t, stdout.String(), "listen 8080;\nreturn 301 $scheme://localhost;\n")
+
+	// From stdin
+	stdout.Reset()
+	streams := cli.Streams{Stdin: strings.NewReader("port=${DOTENV_TEST_PORT}"), Stdout: stdout, Stderr: &bytes.Buffer{}}
+	test.Ok(t, cli.Run([]string{"template", "-env", env}, streams))
+	test.Equal(t, stdout.String(), "port=8080")
+
+	err := cli.Run([]string{"template", "-strict", tmpl}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+	test.Equal(t, err.Error(), tmpl+`:1:8: undefined variable "DOTENV_TEST_PORT"`)
+}
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	src := "USER=me\nAPI_KEY=hunter22\nPORT=80 80\n\n# @secret\nPIN=1234 5678\n"
+	test.Ok(t, os.WriteFile(path, []byte(src), 0o600))
+
+	err := cli.Run([]string{"export", path}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	test.Err(t, err)
+
+	buf := &bytes.Buffer{}
+	cli.Report(buf, err)
+
+	got := buf.String()
+	test.True(t, strings.Contains(got, path+":3:6-11: unquoted value for PORT"), test.Context("output: %s", got))
+	test.True(t, strings.Contains(got, "2 | API_KEY=********\n"), test.Context("output: %s", got))
+	test.True(t, strings.Contains(got, "3 | PORT=80 80\n"), test.Context("output: %s", got))
+	test.True(t, strings.Contains(got, "6 | PIN=*********\n"), test.Context("output: %s", got))
+
+	for _, secret := range []string{"hunter22", "1234", "5678"} {
+		test.False(t, strings.Contains(got, secret), test.Context("%s shown in output: %s", secret, got))
+	}
+
+	// Errors that aren't about a file are shown as they are
+	buf.Reset()
+	cli.Report(buf, cli.Run([]string{"nope"}, cli.Streams{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}))
+	test.Equal(t, buf.String(), "dotenv: unknown command \"nope\"\n")
+}
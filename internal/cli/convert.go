@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// convertedPerm is the permissions of a new converted file, the usual permissions for a
+// config file.
+const convertedPerm fs.FileMode = 0o644
+
+// convertFlags are the flags for convert.
+type convertFlags struct {
+	from   string // Format to convert from
+	to     string // Format to convert to
+	nest   string // Separator to nest keys by
+	output string // File to write to, "" for stdout
+}
+
+// convert implements 'dotenv convert'.
+func convert(args []string, streams Streams) error {
+	var options convertFlags
+
+	flags := newFlagSet(
+		"convert",
+		"[flags] [file]",
+		`Convert variables between .env, JSON, YAML, TOML, docker env files and systemd
+EnvironmentFiles. The input is read from file (default `+dotenv.DefaultFile+`, or "-" for stdin).
+
+The formats default to those implied by the file extensions, so
+
+  dotenv convert -o config.json .env
+
+converts a .env file to JSON, and
+
+  dotenv convert -from systemd -to dotenv /etc/app/env
+
+converts a systemd EnvironmentFile to a .env file. With -nest, keys are split on the separator into
+nested objects and lower cased e.g. with -nest __, APP__DB__HOST becomes app.db.host,
+and nested objects are flattened the same way in reverse.
+
+Comments on variables are kept where the output format has comments.`,
+		streams,
+	)
+	flags.StringVar(&options.from, "from", "", "Format to convert from: "+formatNames()+" (default from the file extension)")
+	flags.StringVar(&options.to, "to", "", "Format to convert to: "+formatNames()+" (default from the -o extension)")
+	flags.StringVar(&options.nest, "nest", "", "Separator splitting keys into nested objects e.g. __")
+	flags.StringVar(&options.output, "o", "", "Write to this file rather than stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("convert takes at most 1 file, got %d", flags.NArg())
+	}
+
+	path := dotenv.DefaultFile
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	from, err := formatFor(options.from, path)
+	if err != nil {
+		return err
+	}
+
+	if options.to == "" && options.output == "" {
+		return errors.New("no output format, pass -to or -o with a file extension")
+	}
+
+	to, err := formatFor(options.to, options.output)
+	if err != nil {
+		return err
+	}
+
+	var conversion []dotenv.Option
+	if options.nest != "" {
+		conversion = append(conversion, dotenv.Nest(options.nest))
+	}
+
+	var data []byte
+	if path == "-" {
+		data, err = io.ReadAll(streams.Stdin)
+		path = "stdin"
+	} else {
+		data, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	env, err := dotenv.Unmarshal(path, data, from, conversion...)
+	if err != nil {
+		return err
+	}
+
+	converted, err := dotenv.Marshal(env, to, conversion...)
+	if err != nil {
+		return err
+	}
+
+	if options.output == "" {
+		_, err = streams.Stdout.Write(converted)
+		return err
+	}
+
+	return os.WriteFile(options.output, converted, convertedPerm) //nolint:gosec // The user chose where to write
+}
+
+// formatFor returns the format called name or, if name is empty, the format implied
+// by the extension of path, .env if there isn't one that means anything.
+func formatFor(name, path string) (dotenv.Format, error) {
+	if name != "" {
+		return parseFormat(name)
+	}
+
+	if format, err := parseFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		return format, nil
+	}
+
+	return dotenv.FormatDotenv, nil
+}
+
+// parseFormat returns the [dotenv.Format] called name.
+func parseFormat(name string) (dotenv.Format, error) {
+	name = strings.ToLower(name)
+	if name == "yml" {
+		return dotenv.FormatYAML, nil
+	}
+
+	for format := dotenv.FormatDotenv; format <= dotenv.FormatSystemd; format++ {
+		if format.String() == name {
+			return format, nil
+		}
+	}
+
+	return dotenv.FormatDotenv, fmt.Errorf("unknown format %q, expected one of %s", name, formatNames())
+}
+
+// formatNames returns the names of the formats, for help and error messages.
+func formatNames() string {
+	var names []string
+	for format := dotenv.FormatDotenv; format <= dotenv.FormatSystemd; format++ {
+		names = append(names, format.String())
+	}
+
+	return strings.Join(names, ", ")
+}
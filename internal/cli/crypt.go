@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+	"go.followtheprocess.codes/dotenv"
+)
+
+// cryptFlags are the flags shared by encrypt and decrypt.
+type cryptFlags struct {
+	keyFile string // Path to the key file, if not taken from the environment
+	write   bool   // Write the result back to the file rather than stdout
+}
+
+// encrypt implements 'dotenv encrypt'.
+func encrypt(args []string, streams Streams) error {
+	return crypt("encrypt", "Encrypt the values in a .env file, leaving the keys and comments readable.", args, streams,
+		func(path string, src []byte, key dotenv.Key) ([]byte, error) {
+			return dotenv.EncryptFile(path, src, key)
+		},
+	)
+}
+
+// decrypt implements 'dotenv decrypt'.
+func decrypt(args []string, streams Streams) error {
+	return crypt("decrypt", "Decrypt the encrypted values in a .env file.", args, streams,
+		func(path string, src []byte, key dotenv.Key) ([]byte, error) {
+			return dotenv.DecryptFile(path, src, key)
+		},
+	)
+}
+
+// transform is the transformation encrypt or decrypt applies to a file.
+type transform func(path string, src []byte, key dotenv.Key) ([]byte, error)
+
+// crypt implements the shared logic of encrypt and decrypt, which differ only in
+// the transformation they apply to the file.
+func crypt(name, description string, args []string, streams Streams, transform transform) error {
+	var options cryptFlags
+
+	flags := newFlagSet(name, "[flags] <file>", description+`
+
+The key is read from the file given by -key, or the environment: either the key
+itself in $`+dotenv.KeyVar+` or the path to a key file in $`+dotenv.KeyFileVar+`.`, streams)
+	flags.StringVar(&options.keyFile, "key", "", "Path to the encryption key file")
+	flags.BoolVar(&options.write, "w", false, "Write the result back to the file instead of stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("%s takes exactly 1 file, got %d", name, flags.NArg())
+	}
+
+	key, err := loadKey(options.keyFile)
+	if err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := transform(path, src, key)
+	if err != nil {
+		return err
+	}
+
+	if !options.write {
+		_, err = streams.Stdout.Write(out)
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
+
+// keygen implements 'dotenv keygen'.
+func keygen(args []string, streams Streams) error {
+	var useAge bool
+
+	flags := newFlagSet("keygen", "[flags]", `Generate a new encryption key and write it to stdout.
+
+By default the key is a symmetric AES-256 key, with -age it is an age X25519
+identity whose public key (for encrypting only) is included as a comment.`, streams)
+	flags.BoolVar(&useAge, "age", false, "Generate an age identity rather than a symmetric key")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return errors.New("keygen takes no arguments")
+	}
+
+	if !useAge {
+		key, err := dotenv.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(streams.Stdout, key)
+
+		return err
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return fmt.Errorf("could not generate age identity: %w", err)
+	}
+
+	_, err = fmt.Fprintf(streams.Stdout, "# public key: %s\n%s\n", identity.Recipient(), identity)
+
+	return err
+}
+
+// loadKey loads the encryption key from path, or the environment if path is empty.
+func loadKey(path string) (dotenv.Key, error) {
+	if path != "" {
+		return dotenv.ReadKey(path)
+	}
+
+	return dotenv.KeyFromEnv()
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/internal/secret"
+)
+
+// Exit codes for diff, following diff(1).
+const (
+	exitDifferent = 1 // The files differ
+	exitTrouble   = 2 // The files could not be compared
+)
+
+// diffFlags are the flags for diff.
+type diffFlags struct {
+	values bool // Report variables whose values differ
+	order  bool // Report variables that are out of order
+	reveal bool // Show the values of secrets
+}
+
+// diff implements 'dotenv diff'.
+func diff(args []string, streams Streams) error {
+	var options diffFlags
+
+	flags := newFlagSet(
+		"diff",
+		"[flags] [<left> <right>]",
+		`Compare two .env files, by default `+dotenv.DefaultFile+` and `+dotenv.ExampleFile+`, reporting
+variables missing from either side and optionally those whose values or order differ.
+
+Values are compared as written, nothing is expanded and no commands are run.
+
+Exits with 0 if there are no differences, 1 if there are and 2 if the files could
+not be compared, so it can be used in CI.`,
+		streams,
+	)
+	flags.BoolVar(&options.values, "values", false, "Report variables whose values differ")
+	flags.BoolVar(&options.order, "order", false, "Report variables that are in a different order")
+	flags.BoolVar(&options.reveal, "reveal", false, "Show the values of secrets rather than redacting them")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	left, right := dotenv.DefaultFile, dotenv.ExampleFile
+
+	switch flags.NArg() {
+	case 0:
+		// Use the defaults
+	case 2: //nolint:mnd // Two files, one for each side
+		left, right = flags.Arg(0), flags.Arg(1)
+	default:
+		flags.Usage()
+		return exitError{err: fmt.Errorf("diff takes 0 or 2 files, got %d", flags.NArg()), code: exitTrouble}
+	}
+
+	leftEnv, err := dotenv.Read(left, dotenv.Literal())
+	if err != nil {
+		return exitError{err: err, code: exitTrouble}
+	}
+
+	rightEnv, err := dotenv.Read(right, dotenv.Literal())
+	if err != nil {
+		return exitError{err: err, code: exitTrouble}
+	}
+
+	count := 0
+	for _, difference := range dotenv.Compare(leftEnv, rightEnv) {
+		show := func(value string) string {
+			if difference.Secret && !options.reveal {
+				return secret.Redacted
+			}
+
+			return strconv.Quote(value)
+		}
+
+		switch difference.Kind {
+		case dotenv.LeftOnly:
+			fmt.Fprintf(streams.Stdout, "%s is missing %s\n", right, difference.Key)
+		case dotenv.RightOnly:
+			fmt.Fprintf(streams.Stdout, "%s is missing %s\n", left, difference.Key)
+		case dotenv.ValueDiffers:
+			if !options.values {
+				continue
+			}
+
+			fmt.Fprintf(
+				streams.Stdout,
+				"%s differs: %s in %s, %s in %s\n",
+				difference.Key, show(difference.Left), left, show(difference.Right), right,
+			)
+		case dotenv.OrderDiffers:
+			if !options.order {
+				continue
+			}
+
+			fmt.Fprintf(streams.Stdout, "%s is in a different position in %s and %s\n", difference.Key, left, right)
+		default:
+			continue
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return exitError{err: fmt.Errorf("%s and %s differ: %d difference(s)", left, right, count), code: exitDifferent}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// examplePerm is the permissions of a new example file, readable by everyone as it's
+// meant to be shared.
+const examplePerm fs.FileMode = 0o644
+
+// exampleFlags are the flags for example.
+type exampleFlags struct {
+	output      string // File to write the example to, merging into it if it exists
+	placeholder string // Replaces values that aren't kept
+}
+
+// example implements 'dotenv example'.
+func example(args []string, streams Streams) error {
+	var options exampleFlags
+
+	flags := newFlagSet(
+		"example",
+		"[flags] [file]",
+		`Generate an example file from a .env file (default `+dotenv.DefaultFile+`), keeping the keys,
+comments and layout but replacing the values of secrets and anything that isn't a
+bool, int or duration with a placeholder.
+
+With -o the example is written to a file, if it already exists only the variables
+it's missing are added so hand-written documentation is kept.`,
+		streams,
+	)
+	flags.StringVar(&options.output, "o", "", "Write to this file, merging into it if it exists (e.g. "+dotenv.ExampleFile+")")
+	flags.StringVar(&options.placeholder, "placeholder", "", "Value to use in place of the values removed")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("example takes at most 1 file, got %d", flags.NArg())
+	}
+
+	path := dotenv.DefaultFile
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	template, err := dotenv.Example(path, src, dotenv.Placeholder(options.placeholder))
+	if err != nil {
+		return err
+	}
+
+	if options.output == "" {
+		_, err = streams.Stdout.Write(template)
+		return err
+	}
+
+	existing, err := os.ReadFile(options.output)
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.WriteFile(options.output, template, examplePerm) //nolint:gosec // The user chose where to write
+	}
+
+	if err != nil {
+		return err
+	}
+
+	merged, err := dotenv.MergeExample(options.output, existing, template)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(options.output, merged, examplePerm) //nolint:gosec // The user chose where to write
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// export implements 'dotenv export'.
+func export(args []string, streams Streams) error {
+	var name string
+
+	flags := newFlagSet(
+		"export",
+		"[flags] [file...]",
+		`Print commands setting the variables in one or more .env files (default `+dotenv.DefaultFile+`),
+for a shell to evaluate e.g.
+
+  eval "$(dotenv export)"                      # bash and zsh
+  dotenv export -shell fish | source           # fish
+  dotenv export -shell powershell | Invoke-Expression
+
+Values are quoted so they're taken literally whatever they contain. Files are
+exported in order, so variables in later files take precedence.`,
+		streams,
+	)
+	flags.StringVar(&name, "shell", "", "Shell to export for: bash, zsh, fish, powershell or nushell (default from $SHELL, or bash)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	explicit := name != ""
+	if !explicit {
+		name = filepath.Base(os.Getenv("SHELL"))
+	}
+
+	shell, err := parseShell(name)
+	if err != nil {
+		if explicit {
+			return err
+		}
+
+		// $SHELL is unset or something else, fall back rather than refusing to work
+		shell = dotenv.Bash
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{dotenv.DefaultFile}
+	}
+
+	var env dotenv.Env
+
+	encoder := dotenv.NewEncoder(streams.Stdout, shell)
+	for _, path := range paths {
+		env, err = dotenv.Read(path)
+		if err != nil {
+			return err
+		}
+
+		if err = encoder.Encode(env); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// parseShell returns the [dotenv.Shell] called name, also accepting the names of
+// their executables e.g. "pwsh" for PowerShell.
+func parseShell(name string) (dotenv.Shell, error) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+
+	switch name {
+	case "pwsh":
+		return dotenv.PowerShell, nil
+	case "nu":
+		return dotenv.Nushell, nil
+	default:
+		for shell := dotenv.Bash; shell <= dotenv.Nushell; shell++ {
+			if shell.String() == name {
+				return shell, nil
+			}
+		}
+
+		return dotenv.Bash, fmt.Errorf("unknown shell %q, expected bash, zsh, fish, powershell or nushell", name)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/dotenv/internal/gen"
+)
+
+// generatedPerm is the permissions of a generated Go file, readable by everyone like
+// the rest of the source code.
+const generatedPerm fs.FileMode = 0o644
+
+// genFlags are the flags for gen.
+type genFlags struct {
+	typeName string // Name of the generated struct
+	pkg      string // Package to generate the code into
+	output   string // File to write the code to
+}
+
+// generate implements 'dotenv gen'.
+func generate(args []string, streams Streams) error {
+	var options genFlags
+
+	flags := newFlagSet("gen", "[flags] [file]", `Generate a Go struct and a Load<type> function from the variables declared in a .env
+example or schema file (default `+dotenv.ExampleFile+`).
+
+Each variable becomes a field with an env tag, documented by the comments above it
+and typed by its @type annotation, or inferred from its example value. Designed to
+be run by go generate:
+
+  //go:generate dotenv gen -type Config .env.example`, streams)
+	flags.StringVar(&options.typeName, "type", "Config", "Name of the generated struct")
+	flags.StringVar(&options.pkg, "pkg", os.Getenv("GOPACKAGE"), "Package to generate the code into (default $GOPACKAGE)")
+	flags.StringVar(&options.output, "o", "", `File to write the code to, "-" for stdout (default <type>_env.go)`)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("gen takes at most 1 file, got %d", flags.NArg())
+	}
+
+	if options.pkg == "" {
+		return errors.New("no package name, pass -pkg or run with go generate")
+	}
+
+	path := dotenv.ExampleFile
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	code, err := gen.Generate(path, src, gen.Config{
+		Type:    options.typeName,
+		Package: options.pkg,
+		Command: strings.Join(append([]string{"dotenv", "gen"}, args...), " "),
+	})
+	if err != nil {
+		return err
+	}
+
+	switch options.output {
+	case "-":
+		_, err = streams.Stdout.Write(code)
+		return err
+	case "":
+		options.output = strings.ToLower(options.typeName) + "_env.go"
+	default:
+		// Write to the file given
+	}
+
+	return os.WriteFile(options.output, code, generatedPerm) //nolint:gosec // The user chose where to write
+}
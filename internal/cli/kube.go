@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// manifestPerm is the permissions of a generated manifest, readable only by the owner
+// as the Secret's base64 values are as good as plain text.
+const manifestPerm fs.FileMode = 0o600
+
+// kubeFlags are the flags for kube.
+type kubeFlags struct {
+	labels   map[string]string // Labels for the objects
+	output   string            // File to write to, "" for stdout
+	manifest dotenv.Manifest   // Names of the objects
+	secrets  []string          // Extra patterns matching secret variables
+}
+
+// kube implements 'dotenv kube'.
+func kube(args []string, streams Streams) error {
+	options := kubeFlags{labels: make(map[string]string)}
+
+	flags := newFlagSet(
+		"kube",
+		"[flags] [file]",
+		`Generate a Kubernetes ConfigMap and Secret from a .env file (default `+dotenv.DefaultFile+`).
+
+Secret variables go in the Secret, base64 encoded, and everything else in the
+ConfigMap. Secrets are those whose names end in _KEY, _TOKEN, _PASSWORD or _SECRET,
+match a -secret pattern, or are marked with @secret in a comment.
+
+  dotenv kube -name app -namespace prod -label app=web .env | kubectl apply -f -`,
+		streams,
+	)
+	flags.StringVar(&options.manifest.Name, "name", "", "Name of the ConfigMap and Secret (required)")
+	flags.StringVar(&options.manifest.SecretName, "secret-name", "", "Name of the Secret, if different from -name")
+	flags.StringVar(&options.manifest.Namespace, "namespace", "", "Namespace of the ConfigMap and Secret")
+	flags.StringVar(&options.output, "o", "", "Write to this file rather than stdout")
+	flags.Func("label", "A label for both objects as key=value, may be repeated", func(text string) error {
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("bad label %q, expected key=value", text)
+		}
+
+		options.labels[key] = value
+
+		return nil
+	})
+	flags.Func("secret", "A glob pattern matching the names of more secret variables, may be repeated", func(pattern string) error {
+		options.secrets = append(options.secrets, pattern)
+		return nil
+	})
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("kube takes at most 1 file, got %d", flags.NArg())
+	}
+
+	path := dotenv.DefaultFile
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	env, err := dotenv.Read(path, dotenv.SecretKeys(options.secrets...))
+	if err != nil {
+		return err
+	}
+
+	options.manifest.Labels = options.labels
+
+	manifest, err := dotenv.Kubernetes(env, options.manifest)
+	if err != nil {
+		return err
+	}
+
+	if options.output == "" {
+		_, err = streams.Stdout.Write(manifest)
+		return err
+	}
+
+	return os.WriteFile(options.output, manifest, manifestPerm)
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// templatePerm is the permissions of an expanded template, readable only by the owner
+// as the values substituted into it may well be secrets.
+const templatePerm fs.FileMode = 0o600
+
+// templateFlags are the flags for template.
+type templateFlags struct {
+	env    string // .env file to take variables from, "" for just the environment
+	output string // File to write to, "" for stdout
+	strict bool   // Whether undefined variables are an error
+}
+
+// template implements 'dotenv template'.
+func template(args []string, streams Streams) error {
+	var options templateFlags
+
+	flags := newFlagSet(
+		"template",
+		"[flags] [template]",
+		`Replace references to variables like ${VAR} in a template, such as an nginx.conf or
+JSON config, with their values. The template is read from the file or stdin if it's
+"-" or not given, like envsubst.
+
+Variables come from the environment, and the file given by -env which takes
+precedence. Only the braced forms are replaced, ${VAR}, ${VAR:-default} and the rest
+of the .env interpolation syntax, so $host in an nginx.conf is left alone. Write $$
+for a literal '$'.
+
+  dotenv template -env .env -strict nginx.conf.tmpl > nginx.conf`,
+		streams,
+	)
+	flags.StringVar(&options.env, "env", "", "A .env file to take variables from, as well as the environment")
+	flags.BoolVar(&options.strict, "strict", false, "Fail on undefined variables, rather than replacing them with nothing")
+	flags.StringVar(&options.output, "o", "", "Write to this file rather than stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("template takes at most 1 file, got %d", flags.NArg())
+	}
+
+	var env dotenv.Env
+	if options.env != "" {
+		var err error
+
+		env, err = dotenv.Read(options.env)
+		if err != nil {
+			return err
+		}
+	}
+
+	var expansion []dotenv.Option
+	if options.strict {
+		expansion = append(expansion, dotenv.NoUnset())
+	}
+
+	input := streams.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		input = file
+	}
+
+	expanded, err := dotenv.ExpandTemplate(input, env, expansion...)
+	if err != nil {
+		return err
+	}
+
+	if options.output == "" {
+		_, err = streams.Stdout.Write(expanded)
+		return err
+	}
+
+	return os.WriteFile(options.output, expanded, templatePerm)
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// validate implements 'dotenv validate'.
+func validate(args []string, streams Streams) error {
+	var schemaPath string
+
+	flags := newFlagSet(
+		"validate",
+		"[flags] [file]",
+		`Validate a .env file (default `+dotenv.DefaultFile+`) against a schema, reporting every
+variable that is missing or malformed.
+
+The schema is read from the file given by -schema, or `+dotenv.SchemaFile+` if it exists,
+otherwise `+dotenv.ExampleFile+`. Variables are declared with annotations in comments e.g.
+
+  # The port to listen on
+  # @type int
+  # @default 8080
+  PORT=`,
+		streams,
+	)
+	flags.StringVar(&schemaPath, "schema", "", "Path to the schema file")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("validate takes at most 1 file, got %d", flags.NArg())
+	}
+
+	path := dotenv.DefaultFile
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	if schemaPath == "" {
+		schemaPath = dotenv.SchemaFile
+		if _, err := os.Stat(schemaPath); errors.Is(err, fs.ErrNotExist) {
+			schemaPath = dotenv.ExampleFile
+		}
+	}
+
+	schema, err := dotenv.ReadSchema(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	env, err := dotenv.Read(path)
+	if err != nil {
+		return err
+	}
+
+	err = dotenv.Validate(env, schema)
+
+	var validationErr *dotenv.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	for _, violation := range validationErr.Violations {
+		fmt.Fprintln(streams.Stdout, violation)
+	}
+
+	return fmt.Errorf("%s: %d invalid variable(s)", path, len(validationErr.Violations))
+}
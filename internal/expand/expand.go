@@ -0,0 +1,316 @@
+// Package expand implements shell-like variable expansion and command substitution
+// for the values in a .env file.
+//
+// The supported forms of expansion are:
+//
+//   - $VAR: The value of VAR
+//   - ${VAR}: The value of VAR
+//   - ${VAR:-default}: The value of VAR if set and non-empty, otherwise default
+//   - ${VAR-default}: The value of VAR if set, otherwise default
+//   - ${VAR:?message}: The value of VAR if set and non-empty, otherwise an error
+//   - ${VAR?message}: The value of VAR if set, otherwise an error
+//   - ${VAR:+alternate}: alternate if VAR is set and non-empty, otherwise empty
+//   - ${VAR+alternate}: alternate if VAR is set, otherwise empty
+//   - ${ref:scheme://...}: The value the reference resolves to e.g. ${ref:file:///run/secrets/db}
+//   - $(command): The output of running command, with trailing newlines removed
+//
+// Which of these are permitted, and how escape sequences are handled, is controlled
+// by a [Config].
+package expand
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Config controls the behaviour of [Expand].
+type Config struct {
+	// Lookup retrieves the value of a variable by name, reporting whether it was set.
+	//
+	// A nil Lookup means no variables are set.
+	Lookup func(name string) (value string, ok bool)
+
+	// Command runs a command for command substitution and returns its output.
+	//
+	// A nil Command disables command substitution, "$(" is then left as is.
+	Command func(cmd string) (output string, err error)
+
+	// Reference resolves a reference for ${ref:...} e.g. "file:///run/secrets/db".
+	//
+	// A nil Reference disables references, they are then a bad expansion.
+	Reference func(ref string) (value string, err error)
+
+	// Escape decodes the escape sequence '\' + char, reporting whether it was valid.
+	//
+	// Decoded characters are never subject to expansion so e.g. "\$" can be used
+	// for a literal dollar sign. A nil Escape means backslashes have no special meaning.
+	Escape func(char byte) (decoded byte, ok bool)
+
+	// Strict makes referencing an unset variable an error, rather than expanding
+	// to the empty string. Forms that specify a default are still permitted.
+	Strict bool
+
+	// BracedOnly disables the unbraced $VAR form, leaving it as is.
+	BracedOnly bool
+
+	// DollarEscape makes "$$" expand to a literal "$".
+	DollarEscape bool
+}
+
+// Error is an error encountered during expansion.
+type Error struct {
+	Msg    string // Description of the error
+	Offset int    // Byte offset into the expanded string at which the error occurred
+}
+
+// Error implements the error interface for [Error].
+func (e Error) Error() string {
+	return e.Msg
+}
+
+// Expand performs expansion on s according to cfg.
+func Expand(s string, cfg Config) (string, error) {
+	e := expander{cfg: cfg, src: s}
+	return e.expand()
+}
+
+// expander holds the state for a single call to [Expand].
+type expander struct {
+	cfg Config          // The expansion config
+	src string          // The string being expanded
+	out strings.Builder // The expanded output
+	pos int             // Current byte offset into src
+}
+
+// expand performs the expansion.
+func (e *expander) expand() (string, error) {
+	for e.pos < len(e.src) {
+		char := e.src[e.pos]
+
+		switch {
+		case char == '\\' && e.cfg.Escape != nil && e.pos+1 < len(e.src):
+			decoded, ok := e.cfg.Escape(e.src[e.pos+1])
+			if !ok {
+				e.out.WriteByte(char)
+				e.pos++
+				continue
+			}
+
+			e.out.WriteByte(decoded)
+			e.pos += 2
+		case char == '$':
+			if err := e.expansion(); err != nil {
+				return "", err
+			}
+		default:
+			e.out.WriteByte(char)
+			e.pos++
+		}
+	}
+
+	return e.out.String(), nil
+}
+
+// expansion handles a single expansion beginning with the '$' at the current position.
+func (e *expander) expansion() error {
+	start := e.pos
+	rest := e.src[e.pos+1:]
+
+	switch {
+	case strings.HasPrefix(rest, "$") && e.cfg.DollarEscape:
+		e.out.WriteByte('$')
+		e.pos += 2
+		return nil
+	case strings.HasPrefix(rest, "{"):
+		end := balanced(rest, '{', '}')
+		if end == -1 {
+			return Error{Offset: start, Msg: "unterminated variable expansion"}
+		}
+
+		e.pos += 1 + end + 1
+		return e.braced(rest[1:end], start)
+	case strings.HasPrefix(rest, "(") && e.cfg.Command != nil:
+		end := balanced(rest, '(', ')')
+		if end == -1 {
+			return Error{Offset: start, Msg: "unterminated command substitution"}
+		}
+
+		e.pos += 1 + end + 1
+		output, err := e.cfg.Command(rest[1:end])
+		if err != nil {
+			return Error{Offset: start, Msg: fmt.Sprintf("command substitution $(%s) failed: %v", rest[1:end], err)}
+		}
+
+		e.out.WriteString(strings.TrimRight(output, "\r\n"))
+		return nil
+	case !e.cfg.BracedOnly && nameLength(rest) > 0:
+		name := rest[:nameLength(rest)]
+		e.pos += 1 + len(name)
+		return e.variable(name, start)
+	default:
+		// Not an expansion, just a dollar sign
+		e.out.WriteByte('$')
+		e.pos++
+		return nil
+	}
+}
+
+// braced expands the contents of a ${...} expansion.
+func (e *expander) braced(body string, offset int) error {
+	if ref, ok := reference(body); ok && e.cfg.Reference != nil {
+		value, err := e.cfg.Reference(ref)
+		if err != nil {
+			return Error{Offset: offset, Msg: err.Error()}
+		}
+
+		e.out.WriteString(value)
+
+		return nil
+	}
+
+	length := nameLength(body)
+	if length == 0 {
+		return Error{Offset: offset, Msg: fmt.Sprintf("bad variable expansion ${%s}, expected a variable name", body)}
+	}
+
+	name, op := body[:length], body[length:]
+	if op == "" {
+		return e.variable(name, offset)
+	}
+
+	// op is now e.g. ":-default" or "+alternate"
+	checkEmpty := strings.HasPrefix(op, ":")
+	op = strings.TrimPrefix(op, ":")
+
+	if op == "" {
+		return Error{Offset: offset, Msg: fmt.Sprintf("bad variable expansion ${%s}, missing operator after ':'", body)}
+	}
+
+	operator, word := op[0], op[1:]
+
+	value, ok := e.lookup(name)
+	set := ok && (!checkEmpty || value != "")
+
+	switch operator {
+	case '-':
+		if set {
+			e.out.WriteString(value)
+			return nil
+		}
+
+		return e.word(word, offset)
+	case '+':
+		if set {
+			return e.word(word, offset)
+		}
+
+		return nil
+	case '?':
+		if set {
+			e.out.WriteString(value)
+			return nil
+		}
+
+		msg := fmt.Sprintf("required variable %q is not set", name)
+		if word != "" {
+			expanded, err := Expand(word, e.cfg)
+			if err != nil {
+				return Error{Offset: offset, Msg: err.Error()}
+			}
+
+			msg = fmt.Sprintf("%s: %s", name, expanded)
+		}
+
+		return Error{Offset: offset, Msg: msg}
+	default:
+		return Error{Offset: offset, Msg: fmt.Sprintf("bad variable expansion ${%s}, unknown operator %q", body, operator)}
+	}
+}
+
+// word expands a default or alternate value and writes it to the output.
+func (e *expander) word(word string, offset int) error {
+	expanded, err := Expand(word, e.cfg)
+	if err != nil {
+		var expandErr Error
+		if errors.As(err, &expandErr) {
+			return Error{Offset: offset, Msg: expandErr.Msg}
+		}
+
+		return err
+	}
+
+	e.out.WriteString(expanded)
+
+	return nil
+}
+
+// variable writes the value of the variable name to the output.
+func (e *expander) variable(name string, offset int) error {
+	value, ok := e.lookup(name)
+	if !ok && e.cfg.Strict {
+		return Error{Offset: offset, Msg: fmt.Sprintf("undefined variable %q", name)}
+	}
+
+	e.out.WriteString(value)
+
+	return nil
+}
+
+// lookup looks up the value of a variable.
+func (e *expander) lookup(name string) (string, bool) {
+	if e.cfg.Lookup == nil {
+		return "", false
+	}
+
+	return e.cfg.Lookup(name)
+}
+
+// reference returns the reference in the body of a ${ref:...} expansion, reporting
+// whether it was one. The reference must begin with a letter, as URL schemes do, so
+// that it can't be confused with a variable called ref and an operator e.g. ${ref:-x}.
+func reference(body string) (string, bool) {
+	ref, ok := strings.CutPrefix(body, "ref:")
+	if !ok || ref == "" {
+		return "", false
+	}
+
+	isLetter := (ref[0] >= 'a' && ref[0] <= 'z') || (ref[0] >= 'A' && ref[0] <= 'Z')
+
+	return ref, isLetter
+}
+
+// balanced returns the index in s of the closing rune that balances the opening
+// rune at the start of s, or -1 if there isn't one.
+func balanced(s string, opening, closing byte) int {
+	depth := 0
+	for i := range len(s) {
+		switch s[i] {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		default:
+			// Nothing to balance
+		}
+	}
+
+	return -1
+}
+
+// nameLength returns the length of the valid variable name at the start of s.
+func nameLength(s string) int {
+	for i := range len(s) {
+		char := s[i]
+
+		isName := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (i > 0 && char >= '0' && char <= '9')
+		if !isName {
+			return i
+		}
+	}
+
+	return len(s)
+}
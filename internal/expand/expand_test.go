@@ -0,0 +1,139 @@
+package expand_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv/internal/expand"
+	"go.followtheprocess.codes/test"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{
+		"USER":  "me",
+		"HOME":  "/home/me",
+		"EMPTY": "",
+	}
+
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	command := func(cmd string) (string, error) {
+		if cmd == "fail" {
+			return "", errors.New("exit status 1")
+		}
+
+		return strings.ToUpper(cmd) + "\n", nil
+	}
+
+	reference := func(ref string) (string, error) {
+		if ref == "file:///missing" {
+			return "", errors.New("no such file")
+		}
+
+		return "<" + ref + ">", nil
+	}
+
+	escape := func(char byte) (byte, bool) {
+		switch char {
+		case 'n':
+			return '\n', true
+		case '$', '\\':
+			return char, true
+		default:
+			return 0, false
+		}
+	}
+
+	tests := []struct {
+		name    string        // Name of the test case
+		src     string        // String to expand
+		want    string        // Expected output
+		wantErr string        // Expected error message, if any
+		cfg     expand.Config // Config to expand with, Lookup is filled in automatically
+	}{
+		{name: "empty", src: "", want: ""},
+		{name: "no expansions", src: "hello there", want: "hello there"},
+		{name: "bare", src: "$USER", want: "me"},
+		{name: "braced", src: "${USER}", want: "me"},
+		{name: "surrounded", src: "${HOME}/.cache", want: "/home/me/.cache"},
+		{name: "bare stops at non name char", src: "$USER-name", want: "me-name"},
+		{name: "lone dollar", src: "costs $ 5", want: "costs $ 5"},
+		{name: "trailing dollar", src: "costs 5$", want: "costs 5$"},
+		{name: "undefined", src: "${NOPE}", want: ""},
+		{name: "undefined strict", src: "hi ${NOPE}", cfg: expand.Config{Strict: true}, wantErr: `undefined variable "NOPE"`},
+		{name: "default unset", src: "${NOPE:-fallback}", want: "fallback"},
+		{name: "default set", src: "${USER:-fallback}", want: "me"},
+		{name: "default empty with colon", src: "${EMPTY:-fallback}", want: "fallback"},
+		{name: "default empty without colon", src: "${EMPTY-fallback}", want: ""},
+		{name: "default strict", src: "${NOPE:-fallback}", cfg: expand.Config{Strict: true}, want: "fallback"},
+		{name: "nested default", src: "${NOPE:-${USER}}", want: "me"},
+		{name: "alternate set", src: "${USER:+yes}", want: "yes"},
+		{name: "alternate unset", src: "${NOPE:+yes}", want: ""},
+		{name: "alternate empty", src: "${EMPTY+yes}", want: "yes"},
+		{name: "required set", src: "${USER:?must be set}", want: "me"},
+		{name: "required unset", src: "${NOPE:?must be set}", wantErr: "NOPE: must be set"},
+		{name: "required no message", src: "${NOPE?}", wantErr: `required variable "NOPE" is not set`},
+		{name: "bad operator", src: "${USER:=x}", wantErr: `bad variable expansion ${USER:=x}, unknown operator '='`},
+		{name: "no name", src: "${}", wantErr: "bad variable expansion ${}, expected a variable name"},
+		{name: "unterminated", src: "${USER", wantErr: "unterminated variable expansion"},
+		{name: "braced only", src: "$USER ${USER}", cfg: expand.Config{BracedOnly: true}, want: "$USER me"},
+		{name: "dollar escape", src: "$$USER", cfg: expand.Config{DollarEscape: true}, want: "$USER"},
+		{name: "no dollar escape", src: "$$USER", want: "$me"},
+		{name: "command disabled", src: "$(whoami)", want: "$(whoami)"},
+		{name: "command", src: "x$(whoami)x", cfg: expand.Config{Command: command}, want: "xWHOAMIx"},
+		{name: "nested parens", src: "$(echo (hi))", cfg: expand.Config{Command: command}, want: "ECHO (HI)"},
+		{
+			name:    "command error",
+			src:     "$(fail)",
+			cfg:     expand.Config{Command: command},
+			wantErr: "command substitution $(fail) failed: exit status 1",
+		},
+		{name: "unterminated command", src: "$(echo", cfg: expand.Config{Command: command}, wantErr: "unterminated command substitution"},
+		{
+			name: "reference",
+			src:  "x${ref:file:///run/secrets/db}x",
+			cfg:  expand.Config{Reference: reference},
+			want: "x<file:///run/secrets/db>x",
+		},
+		{name: "reference error", src: "${ref:file:///missing}", cfg: expand.Config{Reference: reference}, wantErr: "no such file"},
+		{
+			name:    "references disabled",
+			src:     "${ref:op://vault/item}",
+			wantErr: `bad variable expansion ${ref:op://vault/item}, unknown operator 'o'`,
+		},
+		{name: "ref variable", src: "${ref:-default}", cfg: expand.Config{Reference: reference}, want: "default"},
+		{name: "escapes disabled", src: `\$USER\n`, want: `\me\n`},
+		{name: "escapes", src: `\$USER\n\q`, cfg: expand.Config{Escape: escape}, want: "$USER\n\\q"},
+		{name: "trailing backslash", src: `me\`, cfg: expand.Config{Escape: escape}, want: `me\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Lookup = lookup
+
+			got, err := expand.Expand(tt.src, cfg)
+			if tt.wantErr != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			test.Ok(t, err)
+			test.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestExpandErrorOffset(t *testing.T) {
+	_, err := expand.Expand("abc ${NOPE}", expand.Config{Strict: true})
+	test.Err(t, err)
+
+	var expandErr expand.Error
+	test.True(t, errors.As(err, &expandErr), test.Context("error was not an expand.Error"))
+	test.Equal(t, expandErr.Offset, 4)
+}
@@ -0,0 +1,310 @@
+// Package gen generates Go code from a .env.example (or schema) file: a struct with a
+// field for each variable and a function populating it from the environment, named
+// after the struct e.g. LoadConfig for a Config.
+//
+// Generating the struct from the file means renaming or removing a variable breaks
+// compilation, rather than silently producing empty strings in production.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.followtheprocess.codes/dotenv"
+)
+
+// Config configures the generated code.
+type Config struct {
+	Type    string // Name of the generated struct type
+	Package string // Name of the package the code is generated into
+	Command string // The command that generated the code, shown in the header
+}
+
+// field is a single field of the generated struct.
+type field struct {
+	Name     string      // Go name of the field
+	Key      string      // Name of the environment variable
+	GoType   string      // Go type of the field
+	Default  string      // Value used when the variable is not set
+	Pattern  string      // Regular expression the value must match, for regex
+	Doc      []string    // Lines of the doc comment
+	Values   []string    // Allowed values, for enum
+	Kind     dotenv.Type // Type of the variable
+	Required bool        // Whether the variable must be set
+}
+
+// data is the input to the code template.
+type data struct {
+	Config
+
+	Source  string   // Name of the file the code was generated from
+	Load    string   // Name of the function loading the struct e.g. LoadConfig
+	Suffix  string   // Appended to the names of unexported helpers, so several types can share a package
+	Imports []string // Packages the generated code imports, sorted
+	Fields  []field  // Fields of the struct, in the order they were declared
+}
+
+// Generate generates the Go source code for the variables declared in the .env
+// source src, read from the file name.
+//
+// Each variable's type is taken from its @type annotation (see [dotenv.ParseSchema]),
+// or if it has none is inferred from its value in src: an int, bool or duration if it
+// parses as one, otherwise a string.
+func Generate(name string, src []byte, cfg Config) ([]byte, error) {
+	if !token.IsIdentifier(cfg.Type) {
+		return nil, fmt.Errorf("invalid type name %q", cfg.Type)
+	}
+
+	if !token.IsIdentifier(cfg.Package) {
+		return nil, fmt.Errorf("invalid package name %q", cfg.Package)
+	}
+
+	schema, err := dotenv.ParseSchema(name, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// Named after the type so that generating several into a package doesn't clash
+	first, size := utf8.DecodeRuneInString(cfg.Type)
+	suffix := string(unicode.ToUpper(first)) + cfg.Type[size:]
+	load := "Load" + suffix
+	if !token.IsExported(cfg.Type) {
+		load = "load" + suffix
+	}
+
+	in := data{Config: cfg, Source: name, Load: load, Suffix: suffix}
+	imports := map[string]bool{"errors": true, "os": true}
+
+	for _, declared := range schema.Fields() {
+		f := field{
+			Name:     goName(declared.Name),
+			Key:      declared.Name,
+			Default:  declared.Default,
+			Pattern:  declared.Pattern,
+			Values:   declared.Values,
+			Kind:     declared.Type,
+			Required: declared.Required,
+		}
+
+		if !declared.Typed {
+			f.Kind = infer(declared.Example)
+		}
+
+		f.GoType = goType(f.Kind)
+
+		switch f.Kind {
+		case dotenv.TypeInt, dotenv.TypeBool:
+			imports["strconv"] = true
+		case dotenv.TypeDuration:
+			imports["time"] = true
+		case dotenv.TypeURL:
+			imports["net/url"] = true
+		case dotenv.TypeRegex:
+			imports["regexp"] = true
+		default:
+			// Strings and enums need nothing extra
+		}
+
+		if declared.Description != "" {
+			f.Doc = strings.Split(declared.Description, "\n")
+		} else {
+			f.Doc = []string{fmt.Sprintf("%s is loaded from $%s.", f.Name, f.Key)}
+		}
+
+		in.Fields = append(in.Fields, f)
+	}
+
+	for imp := range imports {
+		in.Imports = append(in.Imports, imp)
+	}
+
+	slices.Sort(in.Imports)
+
+	buf := &bytes.Buffer{}
+	if err = code.Execute(buf, in); err != nil {
+		return nil, fmt.Errorf("could not generate code: %w", err)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %w", err)
+	}
+
+	return formatted, nil
+}
+
+// infer infers the type of a variable from its example value.
+func infer(value string) dotenv.Type {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return dotenv.TypeInt
+	}
+
+	if _, err := strconv.ParseBool(value); err == nil {
+		return dotenv.TypeBool
+	}
+
+	if _, err := time.ParseDuration(value); err == nil {
+		return dotenv.TypeDuration
+	}
+
+	return dotenv.TypeString
+}
+
+// goType returns the Go type of a field of the given type.
+func goType(kind dotenv.Type) string {
+	switch kind {
+	case dotenv.TypeInt:
+		return "int"
+	case dotenv.TypeBool:
+		return "bool"
+	case dotenv.TypeDuration:
+		return "time.Duration"
+	case dotenv.TypeURL:
+		return "*url.URL"
+	default:
+		return "string"
+	}
+}
+
+// initialisms are the words written in all capitals in Go names.
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "CSS": true, "DB": true, "DNS": true, "DSN": true, "HTML": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "JWT": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// goName converts the name of an environment variable e.g. "DATABASE_URL" to an
+// exported Go name e.g. "DatabaseURL".
+func goName(key string) string {
+	b := &strings.Builder{}
+
+	words := strings.FieldsFunc(key, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, word := range words {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		b.WriteString(upper[:1])
+		b.WriteString(strings.ToLower(word[1:]))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "Var" + name
+	}
+
+	return name
+}
+
+// code is the template for the generated code.
+var code = template.Must(
+	template.New("code").
+		Funcs(template.FuncMap{"quote": strconv.Quote, "join": strings.Join}).
+		Parse(`// Code generated by "{{ .Command }}"; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ quote . }}
+{{- end }}
+)
+
+{{ range .Fields }}{{ if eq .Kind.String "regex" }}
+// pattern{{ $.Suffix }}{{ .Name }} matches valid values of ${{ .Key }}.
+var pattern{{ $.Suffix }}{{ .Name }} = regexp.MustCompile({{ quote (printf "^(?:%s)$" .Pattern) }})
+{{ end }}{{ end }}
+
+// {{ .Type }} holds the environment variables declared in {{ .Source }}.
+type {{ .Type }} struct {
+{{- range .Fields }}
+{{- range .Doc }}
+	// {{ . }}
+{{- end }}
+	{{ .Name }} {{ .GoType }} ` + "`env:{{ quote .Key }}`" + `
+{{ end -}}
+}
+
+// {{ .Load }} loads a {{ .Type }} from the process environment, returning an error
+// describing every variable that is missing or invalid.
+func {{ .Load }}() ({{ .Type }}, error) {
+	var (
+		cfg  {{ .Type }}
+		errs []error
+	)
+{{ range .Fields }}
+	if value, ok := lookup{{ $.Suffix }}({{ quote .Key }}, {{ quote .Default }}); ok {
+{{- if eq .Kind.String "int" }}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, errors.New("{{ .Key }} must be an int"))
+		}
+
+		cfg.{{ .Name }} = n
+{{- else if eq .Kind.String "bool" }}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, errors.New("{{ .Key }} must be a bool (true or false)"))
+		}
+
+		cfg.{{ .Name }} = b
+{{- else if eq .Kind.String "duration" }}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, errors.New("{{ .Key }} must be a duration e.g. 30s"))
+		}
+
+		cfg.{{ .Name }} = d
+{{- else if eq .Kind.String "url" }}
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" {
+			errs = append(errs, errors.New("{{ .Key }} must be a URL with a scheme"))
+		}
+
+		cfg.{{ .Name }} = u
+{{- else if eq .Kind.String "enum" }}
+		switch value {
+		case {{ range $i, $v := .Values }}{{ if $i }}, {{ end }}{{ quote $v }}{{ end }}:
+			cfg.{{ .Name }} = value
+		default:
+			errs = append(errs, errors.New({{ quote (printf "%s must be one of %s" .Key (join .Values ", ")) }}))
+		}
+{{- else if eq .Kind.String "regex" }}
+		if !pattern{{ $.Suffix }}{{ .Name }}.MatchString(value) {
+			errs = append(errs, errors.New({{ quote (printf "%s must match %s" .Key .Pattern) }}))
+		}
+
+		cfg.{{ .Name }} = value
+{{- else }}
+		cfg.{{ .Name }} = value
+{{- end }}
+	}{{ if .Required }} else {
+		errs = append(errs, errors.New("{{ .Key }} is required but not set"))
+	}{{ end }}
+{{ end }}
+	return cfg, errors.Join(errs...)
+}
+
+// lookup{{ .Suffix }} returns the value of the environment variable key, or fallback if it's
+// unset or empty, and reports whether the result is non-empty.
+func lookup{{ .Suffix }}(key, fallback string) (string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+
+	return fallback, fallback != ""
+}
+`),
+)
@@ -0,0 +1,131 @@
+package gen_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv/internal/gen"
+	"go.followtheprocess.codes/test"
+)
+
+const example = `# The port to listen on
+PORT=8080
+DEBUG=false
+TIMEOUT=30s
+
+# Where the database is
+# @type url
+# @optional
+DATABASE_URL=
+
+LOG_LEVEL=info # @type enum debug info warn
+
+# @type regex [a-z]+
+APP_NAME=
+
+# @default hello
+GREETING=
+`
+
+func TestGenerate(t *testing.T) {
+	code, err := gen.Generate(".env.example", []byte(example), gen.Config{
+		Type:    "Config",
+		Package: "config",
+		Command: "dotenv gen -pkg config",
+	})
+	test.Ok(t, err)
+
+	src := string(code)
+	test.True(t, strings.HasPrefix(src, `// Code generated by "dotenv gen -pkg config"; DO NOT EDIT.`), test.Context("header: %s", src))
+
+	for _, want := range []string{
+		"\t// The port to listen on\n\tPort int `env:\"PORT\"`",
+		"\tDebug bool `env:\"DEBUG\"`",
+		"\tTimeout time.Duration `env:\"TIMEOUT\"`",
+		"\t// Where the database is\n\tDatabaseURL *url.URL `env:\"DATABASE_URL\"`",
+		"\t// LogLevel is loaded from $LOG_LEVEL.\n\tLogLevel string `env:\"LOG_LEVEL\"`",
+		"\tAppName string `env:\"APP_NAME\"`",
+		`case "debug", "info", "warn":`,
+		`var patternConfigAppName = regexp.MustCompile("^(?:[a-z]+)$")`,
+		`lookupConfig("GREETING", "hello")`,
+		"func LoadConfig() (Config, error) {",
+		`errors.New("PORT is required but not set")`,
+	} {
+		test.True(t, strings.Contains(src, want), test.Context("missing %q in:\n%s", want, src))
+	}
+
+	test.False(t, strings.Contains(src, `"DATABASE_URL is required`), test.Context("DATABASE_URL is optional"))
+	test.False(t, strings.Contains(src, `"GREETING is required`), test.Context("GREETING has a default"))
+
+	// The generated code must compile
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "config_env.go", code, parser.ParseComments)
+	test.Ok(t, err)
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("config", fset, []*ast.File{file}, nil)
+	test.Ok(t, err)
+}
+
+func TestGenerateSeveralTypes(t *testing.T) {
+	fset := token.NewFileSet()
+
+	var files []*ast.File
+	for _, typ := range []string{"Config", "secrets"} {
+		code, err := gen.Generate(".env.example", []byte(example), gen.Config{Type: typ, Package: "config"})
+		test.Ok(t, err)
+
+		file, err := parser.ParseFile(fset, typ+"_env.go", code, parser.ParseComments)
+		test.Ok(t, err)
+
+		files = append(files, file)
+	}
+
+	test.True(t, files[1].Scope.Lookup("loadSecrets") != nil, test.Context("unexported types get an unexported load function"))
+
+	// Both in the same package must still compile
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err := conf.Check("config", fset, files, nil)
+	test.Ok(t, err)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string     // Name of the test case
+		src     string     // .env source
+		wantErr string     // Expected error
+		cfg     gen.Config // Config to generate with
+	}{
+		{
+			name:    "bad type",
+			src:     "A=1\n",
+			cfg:     gen.Config{Type: "my-config", Package: "config"},
+			wantErr: `invalid type name "my-config"`,
+		},
+		{
+			name:    "bad package",
+			src:     "A=1\n",
+			cfg:     gen.Config{Type: "Config", Package: ""},
+			wantErr: `invalid package name ""`,
+		},
+		{
+			name:    "bad schema",
+			src:     "# @type float\nA=1\n",
+			cfg:     gen.Config{Type: "Config", Package: "config"},
+			wantErr: `test.env:1:1-14: bad type for A: unknown type "float"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gen.Generate("test.env", []byte(tt.src), tt.cfg)
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package secret classifies the variables in a .env file as secret or not, and
+// redacts the values of secrets from text that might otherwise leak them.
+//
+// A variable is secret if its name matches one of a set of glob patterns e.g. "*_TOKEN",
+// or it is annotated with "@secret" in its inline comment or the comments directly
+// above it.
+package secret
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+)
+
+// Redacted replaces secret values.
+const Redacted = "[REDACTED]"
+
+// Annotation marks a variable as secret when it appears in a comment.
+const Annotation = "@secret"
+
+// minLength is the length below which values are not redacted from free text, as
+// replacing every occurrence of e.g. "1" would mangle text beyond recognition.
+const minLength = 4
+
+// DefaultPatterns are the glob patterns matched against variable names to decide
+// whether they are secret, matching is case insensitive.
+var DefaultPatterns = []string{"*_KEY", "*_TOKEN", "*_PASSWORD", "*_SECRET"}
+
+// Classifier decides which variables are secret.
+type Classifier struct {
+	patterns []string // Glob patterns matching secret variable names, in upper case
+}
+
+// New returns a [Classifier] matching the [DefaultPatterns] along with any extra patterns.
+func New(patterns ...string) Classifier {
+	all := make([]string, 0, len(DefaultPatterns)+len(patterns))
+	for _, pattern := range slices.Concat(DefaultPatterns, patterns) {
+		all = append(all, strings.ToUpper(pattern))
+	}
+
+	return Classifier{patterns: all}
+}
+
+// Key reports whether the variable name key is secret by its name alone.
+func (c Classifier) Key(key string) bool {
+	key = strings.ToUpper(key)
+	for _, pattern := range c.patterns {
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// File returns the assignments in file that are secret, either by name or annotation.
+func (c Classifier) File(file ast.File) map[*ast.Assignment]bool {
+	secrets := make(map[*ast.Assignment]bool)
+
+	annotated := false // Whether the comment block directly above has the annotation
+	for _, statement := range file.Statements {
+		switch node := statement.(type) {
+		case *ast.Comment:
+			annotated = annotated || Annotated(node.Token.Value())
+		case *ast.Assignment:
+			inline := node.Comment != nil && Annotated(node.Comment.Token.Value())
+			if annotated || inline || c.Key(node.Key.Text) {
+				secrets[node] = true
+			}
+
+			annotated = false
+		default:
+			annotated = false
+		}
+	}
+
+	return secrets
+}
+
+// Annotated reports whether the text of a comment marks a variable as secret.
+func Annotated(comment string) bool {
+	return slices.Contains(strings.Fields(comment), Annotation)
+}
+
+// Redact replaces every occurrence of each of values in s with [Redacted].
+//
+// Values shorter than 4 bytes are not redacted, they are too likely to appear by
+// chance. Longer values are replaced first so that a value containing another is
+// redacted in full.
+func Redact(s string, values ...string) string {
+	values = slices.Clone(values)
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+
+	for _, value := range values {
+		if len(value) < minLength {
+			continue
+		}
+
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+
+	return s
+}
+
+// Spans returns the source spans of the values of the given secret assignments,
+// for masking with e.g. [syntax.PrettyConsoleHandler].
+func Spans(secrets map[*ast.Assignment]bool) []syntax.Span {
+	spans := make([]syntax.Span, 0, len(secrets))
+	for assignment := range secrets {
+		if len(assignment.Value) == 0 {
+			continue
+		}
+
+		spans = append(spans, syntax.Span{Start: assignment.ValuePos(), End: assignment.End()})
+	}
+
+	slices.SortFunc(spans, func(a, b syntax.Span) int { return a.Start - b.Start })
+
+	return spans
+}
@@ -0,0 +1,87 @@
+package secret_test
+
+import (
+	"maps"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/parser"
+	"go.followtheprocess.codes/test"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name     string   // Name of the test case
+		key      string   // Variable name to classify
+		patterns []string // Extra patterns
+		want     bool     // Whether key should be secret
+	}{
+		{name: "key", key: "API_KEY", want: true},
+		{name: "token", key: "GITHUB_TOKEN", want: true},
+		{name: "password", key: "DB_PASSWORD", want: true},
+		{name: "secret", key: "CLIENT_SECRET", want: true},
+		{name: "lower case", key: "api_key", want: true},
+		{name: "no suffix", key: "KEY", want: false},
+		{name: "plain", key: "PORT", want: false},
+		{name: "extra pattern", key: "DATABASE_URL", patterns: []string{"database_*"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.Equal(t, secret.New(tt.patterns...).Key(tt.key), tt.want)
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	src := `# @secret
+A=1
+B=2
+# A comment
+
+# @secret
+C=3 # Also a comment
+D=4 # @secret
+E_TOKEN=5
+# Not @secret-ish
+F=6
+`
+	file, err := parser.New("test", []byte(src), nil).Parse()
+	test.Ok(t, err)
+
+	secrets := secret.New().File(file)
+
+	got := make(map[string]bool)
+	for assignment := range secrets {
+		got[assignment.Key.Text] = true
+	}
+
+	test.EqualFunc(t, got, map[string]bool{"A": true, "C": true, "D": true, "E_TOKEN": true}, maps.Equal)
+
+	spans := secret.Spans(secrets)
+	test.Equal(t, len(spans), 4)
+	test.Equal(t, src[spans[0].Start:spans[0].End], "1")
+	test.Equal(t, spans[0], syntax.Span{Start: 12, End: 13})
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name   string   // Name of the test case
+		s      string   // Text to redact
+		want   string   // Expected result
+		values []string // Secret values
+	}{
+		{name: "none", s: "nothing to see", values: nil, want: "nothing to see"},
+		{name: "single", s: "password is hunter2", values: []string{"hunter2"}, want: "password is [REDACTED]"},
+		{name: "repeated", s: "hunter2 hunter2", values: []string{"hunter2"}, want: "[REDACTED] [REDACTED]"},
+		{name: "short", s: "a is 1", values: []string{"1"}, want: "a is 1"},
+		{name: "longest first", s: "abcdefgh", values: []string{"abcd", "abcdefgh"}, want: "[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.Equal(t, secret.Redact(tt.s, tt.values...), tt.want)
+		})
+	}
+}
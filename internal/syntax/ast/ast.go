@@ -0,0 +1,132 @@
+// Package ast defines the syntax tree produced by parsing a .env file.
+package ast
+
+import "go.followtheprocess.codes/dotenv/internal/syntax/token"
+
+// Node is a node in the syntax tree.
+type Node interface {
+	// Pos returns the byte offset of the start of the node.
+	Pos() int
+
+	// End returns the byte offset immediately after the end of the node.
+	End() int
+}
+
+// Statement is a single top level line in a .env file.
+type Statement interface {
+	Node
+	statementNode()
+}
+
+// File is the root node of a parsed .env file.
+type File struct {
+	Name       string      // The name of the file
+	Statements []Statement // The statements in the file, in source order
+}
+
+// Assignments returns the [Assignment] statements in the file, in source order.
+func (f File) Assignments() []*Assignment {
+	var assignments []*Assignment
+	for _, statement := range f.Statements {
+		if assignment, ok := statement.(*Assignment); ok {
+			assignments = append(assignments, assignment)
+		}
+	}
+
+	return assignments
+}
+
+// Assignment is a single variable declaration e.g. 'KEY=value'.
+//
+// In the docker env-file dialect, a line may consist of just 'KEY' meaning the
+// value is taken from the environment. In that case Eq is the zero [token.Token]
+// and [Assignment.Passthrough] reports true.
+//
+// A comment following the value on the same line e.g. 'KEY=value # Docs' is
+// kept as the Comment, it documents the variable.
+type Assignment struct {
+	Comment *Comment      // The inline comment following the value, if any
+	Value   []token.Token // The tokens making up the value, concatenated on evaluation
+	Export  token.Token   // The export keyword, the zero [token.Token] if there wasn't one
+	Key     token.Token   // The identifier naming the variable
+	Eq      token.Token   // The '=' token
+}
+
+// Exported reports whether the assignment was preceded by the export keyword.
+func (a *Assignment) Exported() bool {
+	return a.Export.Is(token.Export)
+}
+
+// Passthrough reports whether the assignment has no '=' and so takes its
+// value from the environment.
+func (a *Assignment) Passthrough() bool {
+	return !a.Eq.Is(token.Eq)
+}
+
+// Pos implements [Node] for an [Assignment].
+func (a *Assignment) Pos() int {
+	return a.Key.Start
+}
+
+// End implements [Node] for an [Assignment].
+func (a *Assignment) End() int {
+	if a.Passthrough() {
+		return a.Key.End
+	}
+
+	if len(a.Value) == 0 {
+		return a.Eq.End
+	}
+
+	_, end := a.Value[len(a.Value)-1].Span()
+
+	return end
+}
+
+// ValuePos returns the offset of the start of the value, including any opening quote,
+// or [Assignment.End] if there is no value.
+func (a *Assignment) ValuePos() int {
+	if len(a.Value) == 0 {
+		return a.End()
+	}
+
+	start, _ := a.Value[0].Span()
+
+	return start
+}
+
+// Comment is a comment, either occupying an entire line or, when attached to an
+// [Assignment], following its value.
+type Comment struct {
+	Token token.Token // The comment token
+}
+
+// Pos implements [Node] for a [Comment].
+func (c *Comment) Pos() int {
+	return c.Token.Start
+}
+
+// End implements [Node] for a [Comment].
+func (c *Comment) End() int {
+	return c.Token.End
+}
+
+// Blank is an empty line, preserved so that tools operating on the syntax
+// tree can retain the grouping of statements.
+type Blank struct {
+	Token token.Token // The newline token
+}
+
+// Pos implements [Node] for a [Blank].
+func (b *Blank) Pos() int {
+	return b.Token.Start
+}
+
+// End implements [Node] for a [Blank].
+func (b *Blank) End() int {
+	return b.Token.End
+}
+
+func (*Assignment) statementNode() {}
+func (*Comment) statementNode()    {}
+func (*Blank) statementNode()      {}
@@ -0,0 +1,33 @@
+// Package corpus provides a corpus of real-world .env files, shared by the tests and
+// fuzz targets that need realistic input.
+package corpus
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// files holds the .env files in the corpus.
+//
+//go:embed *.env
+var files embed.FS
+
+// All returns the contents of every file in the corpus, in name order.
+func All() ([]string, error) {
+	names, err := fs.Glob(files, "*.env")
+	if err != nil {
+		return nil, err
+	}
+
+	srcs := make([]string, 0, len(names))
+	for _, name := range names {
+		src, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		srcs = append(srcs, string(src))
+	}
+
+	return srcs, nil
+}
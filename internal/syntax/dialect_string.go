@@ -0,0 +1,29 @@
+// Code generated by "stringer -type Dialect -linecomment"; DO NOT EDIT.
+
+package syntax
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Strict-0]
+	_ = x[Compose-1]
+	_ = x[DockerEnvFile-2]
+	_ = x[PythonDotenv-3]
+	_ = x[NodeDotenv-4]
+	_ = x[POSIXShell-5]
+}
+
+const _Dialect_name = "StrictComposeDockerEnvFilePythonDotenvNodeDotenvPOSIXShell"
+
+var _Dialect_index = [...]uint8{0, 6, 13, 26, 38, 48, 58}
+
+func (i Dialect) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Dialect_index)-1 {
+		return "Dialect(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Dialect_name[_Dialect_index[idx]:_Dialect_index[idx+1]]
+}
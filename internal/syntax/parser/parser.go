@@ -0,0 +1,297 @@
+// Package parser implements the .env file parser, consuming tokens from the
+// scanner and building the syntax tree defined in the ast package.
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/scanner"
+	"go.followtheprocess.codes/dotenv/internal/syntax/token"
+)
+
+// Parser is the .env file parser.
+type Parser struct {
+	handler syntax.ErrorHandler // The error handler, may be nil
+	scanner *scanner.Scanner    // The scanner providing tokens
+	name    string              // The name of the input file
+	src     []byte              // Raw source text
+	errs    []error             // Errors encountered during parsing
+	current token.Token         // The token currently under inspection
+	prev    token.Token         // The token before current
+	dialect syntax.Dialect      // The dialect of .env syntax being parsed
+	lines   bool                // Whether unquoted values run to the end of the line
+}
+
+// Option is a functional option for configuring a [Parser].
+type Option func(p *Parser)
+
+// WithDialect sets the [syntax.Dialect] the parser should use, the default
+// is [syntax.Strict].
+func WithDialect(dialect syntax.Dialect) Option {
+	return func(p *Parser) {
+		p.dialect = dialect
+	}
+}
+
+// WithLineValues makes unquoted values run to the end of the line regardless
+// of the dialect, see [scanner.WithLineValues].
+func WithLineValues() Option {
+	return func(p *Parser) {
+		p.lines = true
+	}
+}
+
+// New returns a new [Parser].
+//
+// If handler is non-nil it is called for every syntax error encountered, in
+// addition to the errors being returned from [Parser.Parse].
+func New(name string, src []byte, handler syntax.ErrorHandler, options ...Option) *Parser {
+	p := &Parser{
+		handler: handler,
+		name:    name,
+		src:     src,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	// Route scanner errors through the parser so they're recorded too
+	scannerOptions := []scanner.Option{scanner.WithDialect(p.dialect)}
+	if p.lines {
+		scannerOptions = append(scannerOptions, scanner.WithLineValues())
+	}
+
+	p.scanner = scanner.New(name, src, p.report, scannerOptions...)
+
+	// Prime the parser with the first token, prev starts out as a
+	// newline so the start of the file is treated as the start of a line
+	p.prev = token.Token{Kind: token.Newline}
+	p.current = p.scanner.Scan()
+
+	return p
+}
+
+// Parse parses the entire input and returns the syntax tree.
+//
+// If any syntax errors were encountered, the returned error will be the
+// combination of all of them, each a [syntax.Error].
+func (p *Parser) Parse() (ast.File, error) {
+	file := ast.File{Name: p.name}
+
+	for !p.current.Is(token.EOF, token.Error) {
+		statement := p.parseStatement()
+		if statement != nil {
+			file.Statements = append(file.Statements, statement)
+		}
+	}
+
+	return file, errors.Join(p.errs...)
+}
+
+// advance moves the parser onto the next token.
+func (p *Parser) advance() {
+	if p.current.Is(token.EOF, token.Error) {
+		// Nothing more to read
+		return
+	}
+
+	p.prev = p.current
+	p.current = p.scanner.Scan()
+}
+
+// expect asserts that the current token is of the given kind, returning it and advancing
+// the parser if so, and reporting an error otherwise.
+func (p *Parser) expect(kind token.Kind, what string) (token.Token, bool) {
+	tok := p.current
+	if !tok.Is(kind) {
+		p.errorf(tok, "expected %s, got %s", what, describe(tok))
+		return tok, false
+	}
+
+	p.advance()
+
+	return tok, true
+}
+
+// parseStatement parses a single top level statement, returning nil if the
+// statement was invalid and an error has been reported.
+func (p *Parser) parseStatement() ast.Statement {
+	switch tok := p.current; tok.Kind {
+	case token.Newline:
+		p.advance()
+		if p.prev.Is(token.Newline) {
+			// Nothing else on this line
+			return &ast.Blank{Token: tok}
+		}
+		return nil
+	case token.Comment:
+		p.advance()
+		if !p.endOfLine() {
+			return nil
+		}
+		return &ast.Comment{Token: tok}
+	case token.Export, token.Ident:
+		return p.parseAssignment()
+	default:
+		p.errorf(tok, "unexpected %s, expected a variable declaration or a comment", describe(tok))
+		p.synchronise()
+		return nil
+	}
+}
+
+// parseAssignment parses a variable declaration e.g. 'export KEY=value'.
+func (p *Parser) parseAssignment() ast.Statement {
+	assignment := &ast.Assignment{}
+
+	if p.current.Is(token.Export) {
+		if p.dialect == syntax.DockerEnvFile {
+			p.errorf(p.current, "the export keyword is not supported in the %s dialect", p.dialect)
+			p.synchronise()
+			return nil
+		}
+
+		assignment.Export = p.current
+		p.advance()
+	}
+
+	key, ok := p.expect(token.Ident, "a variable name")
+	if !ok {
+		p.synchronise()
+		return nil
+	}
+
+	assignment.Key = key
+
+	if p.dialect == syntax.DockerEnvFile && p.current.Is(token.Newline, token.EOF) {
+		// Docker allows a bare 'KEY' meaning take the value from the environment
+		p.advance()
+		return assignment
+	}
+
+	eq, ok := p.expect(token.Eq, "'='")
+	if !ok {
+		p.synchronise()
+		return nil
+	}
+
+	assignment.Eq = eq
+
+	for isValuePart(p.current) {
+		if len(assignment.Value) != 0 && p.current.Leading != "" {
+			// Parts of a value must be directly adjacent, whitespace ends the value
+			break
+		}
+
+		assignment.Value = append(assignment.Value, p.current)
+		p.advance()
+	}
+
+	if len(assignment.Value) != 0 && isValuePart(p.current) {
+		// Something like 'KEY=hello world', almost certainly meant to be one value
+		start := assignment.ValuePos()
+		_, end := p.current.Span()
+		for isValuePart(p.current) {
+			_, end = p.current.Span()
+			p.advance()
+		}
+
+		// The value is deliberately not echoed back, it may well be a secret
+		p.report(
+			syntax.Locate(p.name, p.src, start, end),
+			fmt.Sprintf("unquoted value for %s cannot contain whitespace, wrap it in quotes", key.Text),
+		)
+		p.synchronise()
+		return nil
+	}
+
+	if p.current.Is(token.InlineComment) {
+		assignment.Comment = &ast.Comment{Token: p.current}
+		p.advance()
+	}
+
+	if !p.endOfLine() {
+		return nil
+	}
+
+	return assignment
+}
+
+// endOfLine asserts that the parser is at the end of a line, consuming the newline
+// (if there is one) and reporting an error if not.
+//
+// It reports whether the end of line was found.
+func (p *Parser) endOfLine() bool {
+	switch p.current.Kind {
+	case token.Newline:
+		p.advance()
+		return true
+	case token.EOF, token.Error:
+		return true
+	default:
+		p.errorf(p.current, "unexpected %s, expected end of line", describe(p.current))
+		p.synchronise()
+		return false
+	}
+}
+
+// synchronise discards tokens until the start of the next line so that parsing
+// can continue after an error.
+func (p *Parser) synchronise() {
+	for !p.current.Is(token.Newline, token.EOF, token.Error) {
+		p.advance()
+	}
+
+	p.advance()
+}
+
+// report records a syntax error and passes it onto the installed handler, if there is one.
+func (p *Parser) report(pos syntax.Position, msg string) {
+	p.errs = append(p.errs, syntax.Error{Pos: pos, Msg: msg})
+
+	if p.handler != nil {
+		p.handler(pos, msg)
+	}
+}
+
+// errorf reports a formatted syntax error at the position of tok.
+func (p *Parser) errorf(tok token.Token, format string, a ...any) {
+	p.report(p.position(tok), fmt.Sprintf(format, a...))
+}
+
+// position calculates the source position of a token.
+func (p *Parser) position(tok token.Token) syntax.Position {
+	start, end := tok.Span()
+
+	return syntax.Locate(p.name, p.src, start, end)
+}
+
+// isValuePart reports whether tok may form part of the value in an assignment.
+func isValuePart(tok token.Token) bool {
+	return tok.Is(
+		token.Ident,
+		token.String,
+		token.RawString,
+		token.VarInterp,
+		token.CmdInterp,
+		token.Eq,
+		token.Export,
+	)
+}
+
+// describe returns a human readable description of a token for use in error messages.
+//
+// Tokens are described by their kind alone, their text could be part of a secret.
+func describe(tok token.Token) string {
+	switch tok.Kind {
+	case token.EOF:
+		return "end of file"
+	case token.Newline:
+		return "newline"
+	default:
+		return tok.Kind.String()
+	}
+}
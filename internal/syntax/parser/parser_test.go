@@ -0,0 +1,289 @@
+package parser_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/corpus"
+	"go.followtheprocess.codes/dotenv/internal/syntax/parser"
+	"go.followtheprocess.codes/test"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case
+		src  string // Source text to parse
+		want string // Dump of the expected syntax tree
+	}{
+		{
+			name: "empty",
+			src:  "",
+			want: "",
+		},
+		{
+			name: "bare",
+			src:  "KEY=value",
+			want: "Assignment KEY [Ident]\n",
+		},
+		{
+			name: "empty value",
+			src:  "KEY=",
+			want: "Assignment KEY []\n",
+		},
+		{
+			name: "spaces around eq",
+			src:  "KEY = value",
+			want: "Assignment KEY [Ident]\n",
+		},
+		{
+			name: "exported",
+			src:  "export KEY=value",
+			want: "Assignment (export) KEY [Ident]\n",
+		},
+		{
+			name: "export as a key",
+			src:  "export=value",
+			want: "Assignment export [Ident]\n",
+		},
+		{
+			name: "exports is not the keyword",
+			src:  "exports=value",
+			want: "Assignment exports [Ident]\n",
+		},
+		{
+			name: "mixed export",
+			src:  "export ONE=1\nTWO=2\nexport\tTHREE=3\n",
+			want: "Assignment (export) ONE [Ident]\nAssignment TWO [Ident]\nAssignment (export) THREE [Ident]\n",
+		},
+		{
+			name: "concatenated parts",
+			src:  "EMAIL=${USER}@email.com",
+			want: "Assignment EMAIL [VarInterp String]\n",
+		},
+		{
+			name: "base64 padding",
+			src:  "KEY=abc==",
+			want: "Assignment KEY [Ident Eq Eq]\n",
+		},
+		{
+			name: "quoted",
+			src:  `KEY="double" # With a comment` + "\n" + `OTHER='single'`,
+			want: "Assignment KEY [String] # With a comment\nAssignment OTHER [RawString]\n",
+		},
+		{
+			name: "comments and blanks",
+			src:  "# A comment\n\nKEY=value\n\n\n# Another\n",
+			want: "Comment # A comment\nBlank\nAssignment KEY [Ident]\nBlank\nBlank\nComment # Another\n",
+		},
+		{
+			name: "inline comment",
+			src:  "KEY=value # The key\nOTHER=\"quoted\" # Another\n",
+			want: "Assignment KEY [Ident] # The key\nAssignment OTHER [String] # Another\n",
+		},
+		{
+			name: "hash in bare value",
+			src:  "URL=https://example.com/#top\n",
+			want: "Assignment URL [Ident String]\n",
+		},
+		{
+			name: "line continuation",
+			src:  "KEY=abc\\\ndef\nOTHER=1\n",
+			want: "Assignment KEY [Ident String]\nAssignment OTHER [Ident]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(tt.name, []byte(tt.src), testFailHandler(t))
+
+			file, err := p.Parse()
+			test.Ok(t, err)
+
+			test.Diff(t, dump(file), tt.want)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case
+		src  string // Source text to parse
+		want string // Expected error message
+	}{
+		{
+			name: "missing eq",
+			src:  "KEY value",
+			want: `missing eq:1:5-10: expected '=', got Ident`,
+		},
+		{
+			name: "export without a key",
+			src:  "export =value",
+			want: `export without a key:1:8-9: expected a variable name, got Eq`,
+		},
+		{
+			name: "value not echoed",
+			src:  `API_KEY "supersecret"`,
+			want: `value not echoed:1:9-22: expected '=', got String`,
+		},
+		{
+			name: "unquoted whitespace",
+			src:  "KEY=hello big world # Comment",
+			want: `unquoted whitespace:1:5-20: unquoted value for KEY cannot contain whitespace, wrap it in quotes`,
+		},
+		{
+			name: "value on its own",
+			src:  `"value"`,
+			want: `value on its own:1:1-8: unexpected String, expected a variable declaration or a comment`,
+		},
+		{
+			name: "scanner error",
+			src:  `KEY="unterminated`,
+			want: `scanner error:1:5-18: unterminated string literal`,
+		},
+		{
+			name: "error on a later line",
+			src:  "OK=1\nNOT OK",
+			want: `error on a later line:2:5-7: expected '=', got Ident`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(tt.name, []byte(tt.src), nil)
+
+			_, err := p.Parse()
+			test.Err(t, err)
+
+			test.Equal(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseLineValues(t *testing.T) {
+	src := "KEY=hello big world # Comment\nOTHER=\"quoted\"\n"
+	p := parser.New("line values", []byte(src), testFailHandler(t), parser.WithLineValues())
+
+	file, err := p.Parse()
+	test.Ok(t, err)
+
+	test.Diff(t, dump(file), "Assignment KEY [String] # Comment\nAssignment OTHER [String]\n")
+}
+
+func TestParseContinuesAfterError(t *testing.T) {
+	src := "ONE=1\nBAD\nTWO=2\nAL SO BAD\nTHREE=3\n"
+
+	var errs []string
+	handler := func(pos syntax.Position, msg string) {
+		errs = append(errs, fmt.Sprintf("%s: %s", pos, msg))
+	}
+
+	file, err := parser.New("continue", []byte(src), handler).Parse()
+	test.Err(t, err)
+
+	test.Equal(t, len(errs), 2, test.Context("wrong number of errors: %v", errs))
+	test.Diff(t, dump(file), "Assignment ONE [Ident]\nAssignment TWO [Ident]\nAssignment THREE [Ident]\n")
+}
+
+func FuzzParser(f *testing.F) {
+	srcs, err := corpus.All()
+	test.Ok(f, err)
+
+	for _, src := range srcs {
+		for dialect := syntax.Strict; dialect <= syntax.POSIXShell; dialect++ {
+			f.Add(src, uint8(dialect), false)
+		}
+	}
+
+	f.Add("KEY=hello world\nexport\n=value\nA=1 B=2\n", uint8(syntax.Strict), false)
+	f.Add("KEY\nexport KEY=value\n", uint8(syntax.DockerEnvFile), false)
+
+	// The parser must not panic, and must produce a well formed tree and errors
+	f.Fuzz(func(t *testing.T, src string, dialect uint8, lines bool) {
+		options := []parser.Option{parser.WithDialect(syntax.Dialect(dialect % uint8(syntax.POSIXShell+1)))}
+		if lines {
+			options = append(options, parser.WithLineValues())
+		}
+
+		var reported int
+		handler := func(pos syntax.Position, msg string) {
+			reported++
+		}
+
+		file, err := parser.New("fuzz", []byte(src), handler, options...).Parse()
+
+		// Every error must be a syntax error pointing somewhere real, and passed to the handler
+		var errs []error
+		if err != nil {
+			errs = []error{err}
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = joined.Unwrap()
+			}
+		}
+
+		test.Equal(t, len(errs), reported, test.Context("errors returned did not match those reported"))
+
+		for _, err := range errs {
+			var syntaxErr syntax.Error
+			test.True(t, errors.As(err, &syntaxErr), test.Context("error %v was not a syntax.Error", err))
+			test.True(t, syntaxErr.Pos.IsValid(), test.Context("error reported at invalid position %s", syntaxErr.Pos))
+		}
+
+		// Statements must be in source order, not overlap and lie within the input
+		end := 0
+		for _, statement := range file.Statements {
+			test.True(t, statement.Pos() >= end, test.Context("statement %T started before the previous one ended", statement))
+			test.True(t, statement.End() >= statement.Pos(), test.Context("statement %T ended before it started", statement))
+			test.True(t, statement.End() <= len(src), test.Context("statement %T ended past the end of the input", statement))
+
+			end = statement.End()
+		}
+	})
+}
+
+// dump renders a compact, human readable representation of a syntax tree.
+func dump(file ast.File) string {
+	s := &strings.Builder{}
+	for _, statement := range file.Statements {
+		switch node := statement.(type) {
+		case *ast.Assignment:
+			s.WriteString("Assignment ")
+			if node.Exported() {
+				s.WriteString("(export) ")
+			}
+
+			kinds := make([]string, 0, len(node.Value))
+			for _, part := range node.Value {
+				kinds = append(kinds, part.Kind.String())
+			}
+
+			fmt.Fprintf(s, "%s [%s]", node.Key.Text, strings.Join(kinds, " "))
+			if node.Comment != nil {
+				fmt.Fprintf(s, " %s", node.Comment.Token.Text)
+			}
+
+			s.WriteString("\n")
+		case *ast.Comment:
+			fmt.Fprintf(s, "Comment %s\n", node.Token.Text)
+		case *ast.Blank:
+			s.WriteString("Blank\n")
+		default:
+			fmt.Fprintf(s, "Unknown %T\n", node)
+		}
+	}
+
+	return s.String()
+}
+
+// testFailHandler returns a [syntax.ErrorHandler] that handles syntax errors by failing
+// the enclosing test.
+func testFailHandler(tb testing.TB) syntax.ErrorHandler {
+	tb.Helper()
+
+	return func(pos syntax.Position, msg string) {
+		tb.Fatalf("%s: %s", pos, msg)
+	}
+}
@@ -21,15 +21,40 @@ const eof = rune(-1)
 type Scanner struct {
 	handler    syntax.ErrorHandler // The error handler
 	name       string              // The name of the input file
+	leading    string              // Leading trivia for the current token
 	src        []byte              // Raw source text
 	start      int                 // The start position of the current token
 	pos        int                 // Current scanner position in src (bytes, 0 indexed)
 	line       int                 // Current line number, 1 indexed
 	lineOffset int                 // Offset at which the current line started
+	dialect    syntax.Dialect      // The dialect of .env syntax to scan
+	onLine     bool                // Whether a token has already been emitted on the current line
+	seenEq     bool                // Whether an '=' has already been emitted on the current line
+	afterEq    bool                // Whether the last token emitted was the first '=' on the line
+	wholeLine  bool                // Whether unquoted values run to the end of the line regardless of dialect
+}
+
+// Option is a functional option for configuring a [Scanner].
+type Option func(s *Scanner)
+
+// WithDialect sets the [syntax.Dialect] the scanner should use, the default
+// is [syntax.Strict].
+func WithDialect(dialect syntax.Dialect) Option {
+	return func(s *Scanner) {
+		s.dialect = dialect
+	}
+}
+
+// WithLineValues makes unquoted values run to the end of the line, as they do in
+// e.g. [syntax.Compose], in dialects where they would otherwise stop at whitespace.
+func WithLineValues() Option {
+	return func(s *Scanner) {
+		s.wholeLine = true
+	}
 }
 
 // New returns a new [Scanner].
-func New(name string, src []byte, handler syntax.ErrorHandler) *Scanner {
+func New(name string, src []byte, handler syntax.ErrorHandler, options ...Option) *Scanner {
 	s := &Scanner{
 		handler: handler,
 		name:    name,
@@ -37,29 +62,52 @@ func New(name string, src []byte, handler syntax.ErrorHandler) *Scanner {
 		line:    1,
 	}
 
+	for _, option := range options {
+		option(s)
+	}
+
 	return s
 }
 
 // Scan scans the input and returns the next token.
 func (s *Scanner) Scan() token.Token {
-	s.skip(unicode.IsSpace)
+	if s.afterEq && s.dialect == syntax.DockerEnvFile {
+		// Docker takes everything after the '=' literally, including whitespace
+		if tok, ok := s.scanLiteralValue(); ok {
+			return tok
+		}
+	}
+
+	s.scanTrivia()
+
+	if s.afterEq && s.lineValues() {
+		if tok, ok := s.scanLineValue(); ok {
+			return tok
+		}
+	}
 
 	switch char := s.next(); char {
 	case eof:
 		return s.token(token.EOF)
+	case '\n':
+		return s.token(token.Newline)
+	case '\r':
+		// Only a "\r\n" pair makes it this far, lone '\r' is trivia
+		s.next()
+		return s.token(token.Newline)
 	case '#':
-		return s.scanComment()
+		return s.scanHash()
 	case '=':
 		return s.token(token.Eq)
 	case '\'':
-		return s.scanRawString()
+		return s.scanRawString('\'')
 	case '"':
 		return s.scanString()
 	case '$':
 		return s.scanExpansion()
 	default:
 		switch {
-		case isIdent(char):
+		case s.isKey(char):
 			return s.scanIdent()
 		case isValue(char):
 			return s.scanValue()
@@ -118,6 +166,64 @@ func (s *Scanner) peek() rune {
 	return char
 }
 
+// last returns the utf8 rune immediately before the scanner's current position, or
+// [eof] if there isn't one.
+func (s *Scanner) last() rune {
+	if s.pos == 0 {
+		return eof
+	}
+
+	char, _ := utf8.DecodeLastRune(s.src[:s.pos])
+
+	return char
+}
+
+// lineValues reports whether the scanner's dialect is one in which unquoted values
+// run to the end of the line, rather than stopping at whitespace.
+func (s *Scanner) lineValues() bool {
+	return s.wholeLine || s.dialect == syntax.Compose || s.dialect == syntax.PythonDotenv || s.dialect == syntax.NodeDotenv
+}
+
+// continuation consumes a line continuation i.e. an unescaped backslash immediately
+// followed by a newline, reporting whether there was one.
+//
+// Only the strict and POSIX shell dialects support line continuations in unquoted values.
+func (s *Scanner) continuation() bool {
+	if s.dialect != syntax.Strict && s.dialect != syntax.POSIXShell {
+		return false
+	}
+
+	// An even number of backslashes are all escaped e.g. 'C:\\'
+	backslashes := len(s.src[s.start:s.pos]) - len(bytes.TrimRight(s.src[s.start:s.pos], `\`))
+	if backslashes%2 == 0 {
+		return false
+	}
+
+	switch rest := s.rest(); {
+	case bytes.HasPrefix(rest, []byte("\n")):
+		s.next()
+		return true
+	case bytes.HasPrefix(rest, []byte("\r\n")):
+		s.next()
+		s.next()
+		return true
+	default:
+		return false
+	}
+}
+
+// isKey reports whether r is valid in the name of a variable in the scanner's dialect.
+//
+// In addition to the usual identifier characters, everything other than the strict and
+// POSIX shell dialects permits '.' in variable names.
+func (s *Scanner) isKey(r rune) bool {
+	if r == '.' {
+		return s.dialect != syntax.Strict && s.dialect != syntax.POSIXShell
+	}
+
+	return isIdent(r)
+}
+
 // rest returns the rest of src from the scanners current position to eof.
 func (s *Scanner) rest() []byte {
 	if s.pos >= len(s.src) {
@@ -152,17 +258,6 @@ func (s *Scanner) take(chars string) bool {
 	return true
 }
 
-// skip ignores any characters for which the predicate returns true, stopping at the
-// first one that returns false such that after it returns, [Scanner.next] returns the
-// first 'false' char.
-//
-// The scanner start position is brought up to the current position before returning, effectively
-// ignoring everything it's travelled over in the meantime.
-func (s *Scanner) skip(predicate func(r rune) bool) {
-	s.takeWhile(predicate)
-	s.discard()
-}
-
 // takeUntil consumes characters until it hits any of the specified runes.
 //
 // It stops before it consumes the first specified rune such that after it returns,
@@ -180,6 +275,29 @@ func (s *Scanner) takeUntil(runes ...rune) {
 	}
 }
 
+// takeBalanced consumes characters up to and including the closing rune that balances an
+// already consumed opening rune, such that nested pairs e.g. "${A:-${B}}" are consumed whole.
+//
+// It reports whether the closing rune was found before eof.
+func (s *Scanner) takeBalanced(opening, closing rune) bool {
+	depth := 1
+	for {
+		switch s.next() {
+		case eof:
+			return false
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return true
+			}
+		default:
+			// Nothing to balance
+		}
+	}
+}
+
 // takeWhile consumes characters so long as the predicate returns true, stopping at the
 // first one that returns false such that after it returns, [Scanner.next] returns the first 'false' rune.
 func (s *Scanner) takeWhile(predicate func(r rune) bool) {
@@ -194,20 +312,55 @@ func (s *Scanner) takeWhile(predicate func(r rune) bool) {
 // The scanner's start position is reset just before returning the token.
 func (s *Scanner) token(kind token.Kind) token.Token {
 	tok := token.Token{
-		Kind:  kind,
-		Start: s.start,
-		End:   s.pos,
+		Kind:    kind,
+		Start:   s.start,
+		End:     s.pos,
+		Text:    string(s.src[s.start:s.pos]),
+		Leading: s.leading,
 	}
 
+	// Inline comments are only inline if something came before them on the same line
+	s.onLine = kind != token.Newline
+
+	// Track whether we're about to scan the value in a 'KEY=value' line, some
+	// dialects treat values very differently to the rest of the line
+	switch kind {
+	case token.Newline:
+		s.seenEq = false
+		s.afterEq = false
+	case token.Eq:
+		s.afterEq = !s.seenEq
+		s.seenEq = true
+	default:
+		s.afterEq = false
+	}
+
+	s.leading = ""
 	s.discard() // Reset the state, we already have the token
 	return tok
 }
 
+// delimited returns a token like [Scanner.token], but with its Start and End spanning
+// only its contents, inside the opening and closing delimiters of the given lengths.
+func (s *Scanner) delimited(kind token.Kind, opening, closing int) token.Token {
+	tok := s.token(kind)
+	tok.Start += opening
+	tok.End -= closing
+
+	return tok
+}
+
 // error calculates the position information and calls the installed error handler
 // with the information, emitting an error token in the process.
 //
 // The returned token is a [token.Error].
 func (s *Scanner) error(msg string) token.Token {
+	// Column is the number of bytes between the last newline and the current position
+	// +1 because columns are 1 indexed. A token spanning multiple lines is reported
+	// from the start of the line the error occurred on.
+	startCol := 1 + max(s.start-s.lineOffset, 0)
+	endCol := 1 + s.pos - s.lineOffset
+
 	// So that even if there is no handler installed, we still know something
 	// went wrong
 	tok := s.token(token.Error)
@@ -217,11 +370,6 @@ func (s *Scanner) error(msg string) token.Token {
 		return tok
 	}
 
-	// Column is the number of bytes between the last newline and the current position
-	// +1 because columns are 1 indexed
-	startCol := 1 + s.start - s.lineOffset
-	endCol := 1 + s.pos - s.lineOffset
-
 	position := syntax.Position{
 		Name:     s.name,
 		Offset:   s.pos,
@@ -240,40 +388,103 @@ func (s *Scanner) errorf(format string, a ...any) token.Token {
 	return s.error(fmt.Sprintf(format, a...))
 }
 
+// scanTrivia consumes any insignificant whitespace before the next token, storing
+// it as the leading trivia to be attached to that token.
+//
+// Newlines are significant and are not trivia, with the exception of a lone '\r'
+// not followed by a '\n'.
+func (s *Scanner) scanTrivia() {
+	for {
+		char := s.peek()
+		if char == '\n' || !unicode.IsSpace(char) || bytes.HasPrefix(s.rest(), []byte("\r\n")) {
+			break
+		}
+
+		s.next()
+	}
+
+	s.leading = string(s.src[s.start:s.pos])
+	s.discard()
+}
+
+// scanHash scans the token starting with the '#' that has just been consumed.
+//
+// A '#' directly after something else on the line e.g. 'abc#def' or a URL
+// fragment is part of a value rather than the start of a comment, except in
+// the node dialect where any '#' starts a comment.
+func (s *Scanner) scanHash() token.Token {
+	if s.onLine && s.leading == "" && s.dialect != syntax.NodeDotenv {
+		return s.scanValue()
+	}
+
+	return s.scanComment()
+}
+
 // scanComment scans a line comment e.g. '# This is a comment'.
 //
-// Effectively, everything up to the next '\n' (or eof) is considered part
-// of the comment.
+// Effectively, everything up to the next newline (or eof) is considered part
+// of the comment. If the comment follows another token on the same line, it
+// is emitted as an [token.InlineComment], in which case the '#' must be preceded
+// by whitespace.
 func (s *Scanner) scanComment() token.Token {
 	s.takeUntil('\n', eof)
+	if s.pos > s.start && s.src[s.pos-1] == '\r' {
+		// Leave the '\r' of a "\r\n" for the newline token
+		s.pos--
+	}
+
+	if s.onLine {
+		return s.token(token.InlineComment)
+	}
+
 	return s.token(token.Comment)
 }
 
-// scanRawString scans a single quoted string literal.
+// scanRawString scans a single quoted string literal (or a backtick quoted one
+// in the [syntax.NodeDotenv] dialect).
 //
 // These are treated as a raw string with no variable interpolation
-// or command substitution allowed.
-func (s *Scanner) scanRawString() token.Token {
-	// We track start and end separately here so we can chop the quotes
-	// off the start and end of the string
-	start := s.pos
-
-	s.takeUntil('\'', eof)
-	if s.peek() == eof {
-		return s.error("unterminated string literal")
+// or command substitution allowed. A single quote may be included by
+// doubling it in the strict dialect, or escaping it in the python dialect:
+//
+//	STRICT='it''s'
+//	PYTHON='it\'s'
+func (s *Scanner) scanRawString(quote rune) token.Token {
+	// The opening quote has already been consumed, only the strict dialect
+	// has multiline strings
+	if quote == '\'' && s.dialect == syntax.Strict && s.take("''") {
+		return s.scanRawMultilineString()
 	}
 
-	end := s.pos
-	tok := token.Token{
-		Kind:  token.RawString,
-		Start: start,
-		End:   end,
+	for {
+		switch char := s.next(); {
+		case char == eof:
+			return s.error("unterminated string literal")
+		case char == '\\' && quote == '\'' && s.dialect == syntax.PythonDotenv:
+			s.next() // Whatever it is, it's escaped
+		case char == quote && quote == '\'' && s.dialect == syntax.Strict && s.peek() == quote:
+			s.next() // A doubled quote is a literal quote
+		case char == quote:
+			return s.delimited(token.RawString, 1, 1)
+		default:
+			// Part of the string
+		}
 	}
+}
 
-	s.next()    // Consume the closing quote
-	s.discard() // Reset, we already have the token
+// scanRawMultilineString scans a literal multiline string delimited by three single
+// quotes, the opening quotes have already been consumed.
+//
+// Like a single quoted string, it ends at the first closing quotes and has no escape
+// sequences or interpolation.
+func (s *Scanner) scanRawMultilineString() token.Token {
+	for !s.take("'''") {
+		if s.next() == eof {
+			return s.error("unterminated multiline string")
+		}
+	}
 
-	return tok
+	return s.delimited(token.RawString, len("'''"), len("'''"))
 }
 
 // scanString scans a double quoted string literal.
@@ -281,68 +492,44 @@ func (s *Scanner) scanRawString() token.Token {
 // Unlike a raw string with single quotes, a double quoted literal may contain
 // variable and/or command interpolation as well as escape sequences.
 func (s *Scanner) scanString() token.Token {
-	// The opening '"' has already been consumed
-	if s.take(`""`) {
-		s.discard()
+	// The opening '"' has already been consumed, only the strict dialect
+	// has multiline strings
+	if s.dialect == syntax.Strict && s.take(`""`) {
 		return s.scanMultilineString()
 	}
 
-	// We track start and end separately here so we can chop the quotes
-	// off the start and end of the string
-	start := s.pos
-
-	s.takeUntil('"', eof)
-	if s.peek() == eof {
-		return s.error("unterminated string literal")
-	}
-
-	// TODO(@FollowTheProcess): Interpolation, I think escape sequences
-	// we can just handle later with Go's string stuff so we don't
-	// need to do anything special here
-
-	end := s.pos
-	tok := token.Token{
-		Kind:  token.String,
-		Start: start,
-		End:   end,
+	for {
+		switch s.next() {
+		case eof:
+			return s.error("unterminated string literal")
+		case '\\':
+			s.next() // An escaped character can't close the string e.g. "say \"hi\""
+		case '"':
+			return s.delimited(token.String, 1, 1)
+		default:
+			// Part of the string
+		}
 	}
-
-	s.next()    // Consume the closing '"'
-	s.discard() // Reset, we have the token now
-
-	return tok
 }
 
 // scanMultilineString scans a '"""' multiline string.
 //
 // The opening 3 quotes have already been consumed.
 func (s *Scanner) scanMultilineString() token.Token {
-	// We track start and end separately to chop off the quotes
-	start := s.pos
-
-	s.takeUntil('"', eof)
-	if s.peek() == eof {
-		return s.error("unterminated string literal")
-	}
-
-	end := s.pos
-
-	// Unlike normal strings, we actually need 2 more quotes to
-	// properly terminate it
-	if !s.take(`"""`) {
-		return s.error("unterminated multiline string")
-	}
-
-	// TODO(@FollowTheProcess): Interpolation
-
-	tok := token.Token{
-		Kind:  token.String,
-		Start: start,
-		End:   end,
+	// Only the closing """ terminates the string, lone quotes are fine and
+	// an escaped quote can never be part of the closing quotes
+	for !s.take(`"""`) {
+		switch s.next() {
+		case eof:
+			return s.error("unterminated multiline string")
+		case '\\':
+			s.next()
+		default:
+			// Part of the string
+		}
 	}
 
-	s.discard()
-	return tok
+	return s.delimited(token.String, len(`"""`), len(`"""`))
 }
 
 // scanExpansion scans an expansion begun with a '$' in any of the following forms:
@@ -352,73 +539,162 @@ func (s *Scanner) scanMultilineString() token.Token {
 //
 // The opening '$' has already been consumed by Scan.
 func (s *Scanner) scanExpansion() token.Token {
-	// We don't care about the '$' other than the fact it got us here
-	s.discard()
-
 	switch next := s.next(); next {
 	case '{':
 		// ${VAR}
-		s.discard() // We don't actually want the '{'
-		start := s.pos
-		s.takeUntil('}', eof)
-		if s.peek() == eof {
+		if !s.takeBalanced('{', '}') {
 			return s.error("unterminated variable expansion")
 		}
-		end := s.pos
-		s.next() // Consume the closing '}'
-		s.discard()
-		return token.Token{
-			Kind:  token.VarInterp,
-			Start: start,
-			End:   end,
-		}
+		return s.delimited(token.VarInterp, len("${"), len("}"))
 	case '(':
 		// $(<cmd>)
-		s.discard() // We don't want the '(' either
-		start := s.pos
-		s.takeUntil(')', eof)
-		if s.peek() == eof {
+		if !s.takeBalanced('(', ')') {
 			return s.error("unterminated command expansion")
 		}
-		end := s.pos
-		s.next() // Consume the closing ')'
-		s.discard()
-		return token.Token{
-			Kind:  token.CmdInterp,
-			Start: start,
-			End:   end,
-		}
+		return s.delimited(token.CmdInterp, len("$("), len(")"))
+	case eof:
+		return s.error("unterminated expansion")
 	default:
 		if isIdent(next) {
 			// $VAR
 			s.takeWhile(isIdent)
-			return s.token(token.VarInterp)
+			return s.delimited(token.VarInterp, len("$"), 0)
 		}
 		return s.errorf("unexpected char %q, '$' must be followed by one of '(' or '{'", next)
 	}
 }
 
-// scanIdent scans a raw identifier e.g. name of an env var.
+// scanLineValue scans an unquoted value in the dialects where unquoted values run
+// to the end of the line, stopping before an inline comment or a newline. Trailing
+// whitespace is not part of the value and is left as trivia for the next token.
+//
+// It reports whether it scanned a value, if the next thing on the line is not an
+// unquoted value (e.g. a quoted string or the end of the line), the scanner is left
+// untouched and ok is false.
+func (s *Scanner) scanLineValue() (tok token.Token, ok bool) {
+	switch char := s.peek(); {
+	case char == eof, char == '\n', bytes.HasPrefix(s.rest(), []byte("\r\n")):
+		return token.Token{}, false
+	case char == '\'', char == '"', char == '`' && s.dialect == syntax.NodeDotenv:
+		s.next()
+
+		if char == '"' {
+			return s.scanString(), true
+		}
+
+		return s.scanRawString(char), true
+	case char == '#' && (s.dialect == syntax.NodeDotenv || s.leading != ""):
+		// Node treats any '#' as the start of a comment, others require
+		// it to be preceded by whitespace
+		return token.Token{}, false
+	}
+
+	for {
+		char := s.peek()
+		if char == eof || char == '\n' || bytes.HasPrefix(s.rest(), []byte("\r\n")) {
+			break
+		}
+
+		if char == '#' && (s.dialect == syntax.NodeDotenv || isBlank(s.last())) {
+			break
+		}
+
+		if char == '\\' {
+			s.next()
+			s.continuation()
+			continue
+		}
+
+		s.next()
+	}
+
+	// Trailing whitespace is trivia for the next token
+	for s.pos > s.start && unicode.IsSpace(s.last()) {
+		_, width := utf8.DecodeLastRune(s.src[s.start:s.pos])
+		s.pos -= width
+	}
+
+	return s.token(token.String), true
+}
+
+// scanLiteralValue scans the rest of the line as a literal value, as is done in the
+// [syntax.DockerEnvFile] dialect.
+//
+// It reports whether it scanned a value, ok is false if the value is empty.
+func (s *Scanner) scanLiteralValue() (tok token.Token, ok bool) {
+	s.takeUntil('\n', eof)
+	if s.pos > s.start && s.src[s.pos-1] == '\r' {
+		// Leave the '\r' of a "\r\n" for the newline token
+		s.pos--
+	}
+
+	if s.pos == s.start {
+		return token.Token{}, false
+	}
+
+	return s.token(token.String), true
+}
+
+// scanIdent scans a raw identifier e.g. name of an env var, or the
+// export keyword.
 func (s *Scanner) scanIdent() token.Token {
-	// export is ignored, but the 'e' has already been consumed when Scan
-	// called next()
-	if s.take("xport") {
-		s.discard()
-		s.skip(unicode.IsSpace)
+	s.takeWhile(s.isKey)
+
+	// export is only a keyword if it's followed by whitespace, otherwise
+	// things like "exports=1" or "export=1" are perfectly good identifiers
+	if string(s.src[s.start:s.pos]) == "export" && isBlank(s.peek()) {
+		return s.token(token.Export)
 	}
 
-	s.takeWhile(isIdent)
 	return s.token(token.Ident)
 }
 
-// scanValue scans an env var value.
+// scanValue scans an env var value, up to the next whitespace that is not
+// part of a line continuation.
 //
 // It is emitted as a String.
 func (s *Scanner) scanValue() token.Token {
-	s.takeWhile(isValue)
+	if s.dialect == syntax.POSIXShell && s.last() == '\\' && isEscapable(s.peek()) {
+		// The value started with an escape e.g. \' or '\ '
+		s.next()
+	}
+
+	s.takeValue()
+	for s.continuation() {
+		s.takeValue()
+	}
+
 	return s.token(token.String)
 }
 
+// takeValue consumes the characters of an unquoted value.
+//
+// In the [syntax.POSIXShell] dialect, like in a shell, an unescaped quote ends the
+// unquoted part of a value so the following is three parts:
+//
+//	KEY='it'\''s'
+//
+// A backslash escapes any character but a newline, so whitespace and quotes
+// can be part of an unquoted word e.g. 'KEY=a\ b'.
+func (s *Scanner) takeValue() {
+	if s.dialect != syntax.POSIXShell {
+		s.takeWhile(isValue)
+		return
+	}
+
+	for {
+		char := s.peek()
+		if !isValue(char) || char == '\'' || char == '"' {
+			return
+		}
+
+		s.next()
+		if char == '\\' && isEscapable(s.peek()) {
+			s.next()
+		}
+	}
+}
+
 // isAlpha reports whether r is an alpha character.
 func isAlpha(r rune) bool {
 	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
@@ -434,9 +710,21 @@ func isIdent(r rune) bool {
 	return isAlpha(r) || isDigit(r) || r == '_' || r == '-'
 }
 
+// isBlank reports whether r is horizontal whitespace i.e. a space or a tab.
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// isEscapable reports whether r may be escaped by a backslash in an unquoted
+// [syntax.POSIXShell] value, which is anything but a newline as that makes a
+// line continuation.
+func isEscapable(r rune) bool {
+	return r != '\n' && r != '\r' && r != eof
+}
+
 // isValue reports whether r is valid in an environment variable value.
 //
-// Basically anything other than a space is okay really.
+// Basically anything other than whitespace is okay really.
 func isValue(r rune) bool {
 	return !unicode.IsSpace(r) && r != eof
 }
@@ -17,6 +17,32 @@ import (
 // a non-nil handler was provided, it is called with the position info and error message.
 type ErrorHandler func(pos Position, msg string)
 
+// Dialect is a flavour of .env syntax, different tools disagree on things like
+// quoting, comments and interpolation and the dialect controls which set of rules
+// the scanner, parser and evaluator apply.
+type Dialect int
+
+//go:generate stringer -type Dialect -linecomment
+const (
+	Strict        Dialect = iota // Strict
+	Compose                      // Compose
+	DockerEnvFile                // DockerEnvFile
+	PythonDotenv                 // PythonDotenv
+	NodeDotenv                   // NodeDotenv
+	POSIXShell                   // POSIXShell
+)
+
+// Error is a syntax error encountered at a particular source position.
+type Error struct {
+	Msg string   // The error message
+	Pos Position // Where in the source the error occurred
+}
+
+// Error implements the error interface for [Error].
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
 // Position is an arbitrary source file position including file, line
 // and column information. It can also express a range of source via StartCol
 // and EndCol, this is useful for error reporting.
@@ -28,7 +54,7 @@ type Position struct {
 	Offset   int    // Byte offset of the position from the start of the file
 	Line     int    // Line number (1 indexed)
 	StartCol int    // Start column (1 indexed)
-	EndCol   int    // End column (1 indexed), EndCol == StartCol when pointing to a single character
+	EndCol   int    // End column (1 indexed, exclusive), StartCol+1 when pointing to a single character
 }
 
 // IsValid reports whether the [Position] describes a valid source position.
@@ -53,8 +79,9 @@ func (p Position) IsValid() bool {
 //
 // Depending on which fields are set, the string returned will be different:
 //
-//   - "file:line:start-end": valid position pointing to a range of text on the line
-//   - "file:line:start": valid position pointing to a single character on the line (EndCol == StartCol)
+//   - "file:line:start-end": valid position pointing to a range of text on the line, end is
+//     exclusive so a single character is "file:line:start-(start+1)"
+//   - "file:line:start": valid position pointing between two characters (EndCol == StartCol)
 //
 // At least Name, Line and StartCol must be present for a valid position, and Line and StarCol must be > 0. If not, an error
 // string will be returned.
@@ -77,9 +104,45 @@ func (p Position) String() string {
 	return fmt.Sprintf("%s:%d:%d-%d", p.Name, p.Line, p.StartCol, p.EndCol)
 }
 
+// Locate calculates the [Position] of the span of src between the byte offsets
+// start and end.
+//
+// If the span covers multiple lines, the position refers to the line on which it starts.
+func Locate(name string, src []byte, start, end int) Position {
+	start = min(max(start, 0), len(src))
+	before := src[:start]
+	lineOffset := bytes.LastIndexByte(before, '\n') + 1
+
+	startCol := 1 + start - lineOffset
+	endCol := max(1+end-lineOffset, startCol)
+
+	if lineEnd := bytes.IndexByte(src[start:], '\n'); lineEnd != -1 {
+		// Clamp multi-line spans to the end of the first line
+		endCol = min(endCol, startCol+lineEnd)
+	}
+
+	return Position{
+		Name:     name,
+		Offset:   start,
+		Line:     1 + bytes.Count(before, []byte("\n")),
+		StartCol: startCol,
+		EndCol:   endCol,
+	}
+}
+
+// Span is a span of source text between two byte offsets.
+type Span struct {
+	Start int // Byte offset of the start of the span
+	End   int // Byte offset immediately after the end of the span
+}
+
 // PrettyConsoleHandler returns a [ErrorHandler] that formats the syntax error for
 // display on the terminal to a user.
-func PrettyConsoleHandler(w io.Writer) ErrorHandler {
+//
+// The source text in each of the masked spans is replaced by '*' when showing the
+// context of the error, so that e.g. secret values are not shown. Line breaks and
+// the length of the masked text are kept so the error is still underlined correctly.
+func PrettyConsoleHandler(w io.Writer, masked ...Span) ErrorHandler {
 	return func(pos Position, msg string) {
 		// TODO(@FollowTheProcess): This is a bit better but still some improvement I think
 		fmt.Fprintf(w, "%s: %s\n\n", pos, msg)
@@ -90,12 +153,13 @@ func PrettyConsoleHandler(w io.Writer) ErrorHandler {
 			return
 		}
 
+		contents = mask(contents, masked)
 		lines := bytes.Split(contents, []byte("\n"))
 
 		const contextLines = 3
 
 		startLine := max(pos.Line-contextLines, 0)
-		endLine := max(pos.Line+contextLines, len(lines))
+		endLine := min(pos.Line+contextLines, len(lines))
 
 		for i, line := range lines {
 			i++ // Lines are 1 indexed
@@ -115,3 +179,22 @@ func PrettyConsoleHandler(w io.Writer) ErrorHandler {
 		}
 	}
 }
+
+// mask returns a copy of src with the text in each of spans, other than line
+// breaks, replaced by '*'.
+func mask(src []byte, spans []Span) []byte {
+	if len(spans) == 0 {
+		return src
+	}
+
+	masked := bytes.Clone(src)
+	for _, span := range spans {
+		for i := max(span.Start, 0); i < min(span.End, len(masked)); i++ {
+			if masked[i] != '\n' && masked[i] != '\r' {
+				masked[i] = '*'
+			}
+		}
+	}
+
+	return masked
+}
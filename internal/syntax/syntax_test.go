@@ -1,7 +1,11 @@
 package syntax_test
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"go.followtheprocess.codes/dotenv/internal/syntax"
@@ -138,3 +142,18 @@ func FuzzPosition(f *testing.F) {
 		test.Equal(t, got, want)
 	})
 }
+
+func TestPrettyConsoleHandlerMasked(t *testing.T) {
+	src := "USER=me\nAPI_KEY=hunter22\nPORT=oops\n"
+	path := filepath.Join(t.TempDir(), ".env")
+	test.Ok(t, os.WriteFile(path, []byte(src), 0o600))
+
+	buf := &bytes.Buffer{}
+	handler := syntax.PrettyConsoleHandler(buf, syntax.Span{Start: 16, End: 24})
+	handler(syntax.Position{Name: path, Line: 3, StartCol: 6, EndCol: 10}, "bad port")
+
+	got := buf.String()
+	test.True(t, strings.Contains(got, "2 | API_KEY=********\n"), test.Context("output: %s", got))
+	test.True(t, strings.Contains(got, "1 | USER=me\n"), test.Context("output: %s", got))
+	test.False(t, strings.Contains(got, "hunter22"), test.Context("output: %s", got))
+}
@@ -17,15 +17,19 @@ func _() {
 	_ = x[Ident-6]
 	_ = x[VarInterp-7]
 	_ = x[CmdInterp-8]
+	_ = x[Newline-9]
+	_ = x[Export-10]
+	_ = x[InlineComment-11]
 }
 
-const _Kind_name = "EOFErrorCommentEqRawStringStringIdentVarInterpCmdInterp"
+const _Kind_name = "EOFErrorCommentEqRawStringStringIdentVarInterpCmdInterpNewlineExportInlineComment"
 
-var _Kind_index = [...]uint8{0, 3, 8, 15, 17, 26, 32, 37, 46, 55}
+var _Kind_index = [...]uint8{0, 3, 8, 15, 17, 26, 32, 37, 46, 55, 62, 68, 81}
 
 func (i Kind) String() string {
-	if i < 0 || i >= Kind(len(_Kind_index)-1) {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Kind_index)-1 {
 		return "Kind(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _Kind_name[_Kind_index[i]:_Kind_index[i+1]]
+	return _Kind_name[_Kind_index[idx]:_Kind_index[idx+1]]
 }
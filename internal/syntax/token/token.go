@@ -4,6 +4,7 @@ package token
 import (
 	"fmt"
 	"slices"
+	"strings"
 )
 
 // Kind is the kind of token.
@@ -11,22 +12,33 @@ type Kind int
 
 //go:generate stringer -type Kind -linecomment
 const (
-	EOF       Kind = iota // EOF
-	Error                 // Error
-	Comment               // Comment
-	Eq                    // Eq
-	RawString             // RawString
-	String                // String
-	Ident                 // Ident
-	VarInterp             // VarInterp
-	CmdInterp             // CmdInterp
+	EOF           Kind = iota // EOF
+	Error                     // Error
+	Comment                   // Comment
+	Eq                        // Eq
+	RawString                 // RawString
+	String                    // String
+	Ident                     // Ident
+	VarInterp                 // VarInterp
+	CmdInterp                 // CmdInterp
+	Newline                   // Newline
+	Export                    // Export
+	InlineComment             // InlineComment
 )
 
 // Token is a lexical token in a .env file.
+//
+// Tokens are lossless, that is, concatenating the Leading trivia and the Text
+// of every token in a stream reproduces the original source exactly.
+//
+// Start and End span the contents of quoted strings and expansions, inside the quotes
+// or delimiters, whereas Text includes them. [Token.Span] gives the offsets of Text.
 type Token struct {
-	Kind  Kind // The type of token this is
-	Start int  // Byte offset from the start of the file to the start of this token
-	End   int  // Byte offset from the start of the file to the end of this token
+	Text    string // The raw source text of the token, including any quotes or delimiters
+	Leading string // Leading trivia (insignificant whitespace) immediately preceding the token, if any
+	Kind    Kind   // The type of token this is
+	Start   int    // Byte offset from the start of the file to the start of this token
+	End     int    // Byte offset from the start of the file to the end of this token
 }
 
 // String implement [fmt.Stringer] for a [Token].
@@ -38,3 +50,212 @@ func (t Token) String() string {
 func (t Token) Is(kinds ...Kind) bool {
 	return slices.Contains(kinds, t.Kind)
 }
+
+// Span returns the byte offsets of the start and end of the token's Text, which unlike
+// Start and End include any quotes or delimiters around its contents.
+func (t Token) Span() (start, end int) {
+	delimiters := len(t.Text) - (t.End - t.Start)
+	if delimiters <= 0 {
+		return t.Start, t.End
+	}
+
+	opening := 1 // A single quote, or the '$' of $VAR
+	switch {
+	case strings.HasPrefix(t.Text, `"""`), strings.HasPrefix(t.Text, "'''"):
+		opening = len(`"""`)
+	case strings.HasPrefix(t.Text, "${"), strings.HasPrefix(t.Text, "$("):
+		opening = len("${")
+	default:
+		// Just the one character
+	}
+
+	return t.Start - opening, t.End + delimiters - opening
+}
+
+// Value returns the decoded value of the token.
+//
+// Quotes and delimiters are removed and, in the case of double quoted strings,
+// escape sequences are decoded. Variable interpolation and command substitution
+// are not performed, they are the responsibility of the evaluator.
+//
+// The value of each kind of token is as follows:
+//
+//   - [String]: The contents between the quotes, with escape sequences decoded
+//   - [RawString]: The contents between the quotes (or backticks), verbatim except
+//     that a doubled single quote is a literal single quote
+//   - [VarInterp]: The name of the variable being referenced e.g. "USER" for "${USER}"
+//   - [CmdInterp]: The command to be run e.g. "whoami" for "$(whoami)"
+//   - [Comment], [InlineComment]: The text of the comment with the '#' and surrounding whitespace removed
+//   - Everything else: The raw text of the token
+//
+// The contents of multiline strings, delimited by three double or three single
+// quotes, are passed through [Dedent] before anything else.
+func (t Token) Value() string {
+	switch t.Kind {
+	case String:
+		if strings.HasPrefix(t.Text, `"""`) {
+			return Unescape(Dedent(trim(t.Text, `"""`, `"""`)))
+		}
+
+		return Unescape(trim(t.Text, `"`, `"`))
+	case RawString:
+		if t.Text == "" {
+			return ""
+		}
+
+		if strings.HasPrefix(t.Text, "'''") {
+			return Dedent(trim(t.Text, "'''", "'''"))
+		}
+
+		// Usually single quotes, but backticks in some dialects
+		quote := t.Text[:1]
+		if quote == "'" {
+			// A doubled single quote is a literal one e.g. 'it''s'
+			return strings.ReplaceAll(trim(t.Text, quote, quote), "''", "'")
+		}
+
+		return trim(t.Text, quote, quote)
+	case VarInterp:
+		if strings.HasPrefix(t.Text, "${") {
+			return trim(t.Text, "${", "}")
+		}
+
+		return strings.TrimPrefix(t.Text, "$")
+	case CmdInterp:
+		return trim(t.Text, "$(", ")")
+	case Comment, InlineComment:
+		return strings.TrimSpace(strings.TrimPrefix(t.Text, "#"))
+	default:
+		return t.Text
+	}
+}
+
+// Dedent removes the formatting of the contents of a multiline string, so that
+// they may be indented along with the rest of the file.
+//
+// It works like Python's textwrap.dedent:
+//
+//   - A newline immediately after the opening quotes is removed
+//   - A newline immediately before the closing quotes is removed, along with any
+//     whitespace indenting the closing quotes
+//   - The longest run of leading spaces and tabs common to every line is removed from
+//     each of them, lines consisting only of whitespace are ignored for this purpose and
+//     are emptied
+//
+// Whitespace is otherwise preserved exactly, as are "\r\n" line endings.
+func Dedent(s string) string {
+	s = strings.TrimPrefix(s, "\n")
+	s = strings.TrimPrefix(s, "\r\n")
+
+	if last := strings.LastIndexByte(s, '\n'); last != -1 && strings.TrimLeft(s[last+1:], " \t") == "" {
+		s = strings.TrimSuffix(s[:last], "\r")
+	}
+
+	lines := strings.Split(s, "\n")
+
+	indent := ""
+	first := true
+	for i, line := range lines {
+		content := strings.TrimRight(line, "\r")
+		if strings.TrimLeft(content, " \t") == "" {
+			// Whitespace only, keep just the line ending
+			lines[i] = line[len(content):]
+			continue
+		}
+
+		leading := content[:len(content)-len(strings.TrimLeft(content, " \t"))]
+		if first {
+			indent = leading
+			first = false
+			continue
+		}
+
+		indent = commonPrefix(indent, leading)
+	}
+
+	if indent == "" {
+		return strings.Join(lines, "\n")
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unescape decodes the escape sequences permitted in a double quoted string.
+//
+// The recognised escape sequences are:
+//
+//   - \n: Newline
+//   - \r: Carriage return
+//   - \t: Tab
+//   - \\: A literal backslash
+//   - \": A literal double quote
+//   - \$: A literal dollar sign
+//
+// Any other sequence beginning with a backslash is left exactly as it was.
+func Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		// Fast path, nothing to do
+		return s
+	}
+
+	b := &strings.Builder{}
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		decoded, ok := Escape(s[i+1])
+		if !ok {
+			// Unknown escape, keep the backslash
+			b.WriteByte(s[i])
+			continue
+		}
+
+		b.WriteByte(decoded)
+		i++ // Skip over the escaped character
+	}
+
+	return b.String()
+}
+
+// Escape returns the byte represented by the escape sequence '\' + char, and
+// reports whether char forms a valid escape sequence.
+func Escape(char byte) (byte, bool) {
+	switch char {
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case '\\', '"', '$':
+		return char, true
+	default:
+		return 0, false
+	}
+}
+
+// commonPrefix returns the longest prefix shared by a and b.
+func commonPrefix(a, b string) string {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return a[:i]
+		}
+	}
+
+	return a[:n]
+}
+
+// trim removes prefix and suffix from s, if present.
+func trim(s, prefix, suffix string) string {
+	s = strings.TrimPrefix(s, prefix)
+	return strings.TrimSuffix(s, suffix)
+}
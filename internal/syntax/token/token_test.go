@@ -6,6 +6,7 @@ import (
 	"testing/quick"
 
 	"go.followtheprocess.codes/dotenv/internal/syntax/token"
+	"go.followtheprocess.codes/test"
 )
 
 func TestString(t *testing.T) {
@@ -24,3 +25,129 @@ func TestString(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name string      // Name of the test case
+		want string      // Expected decoded value
+		tok  token.Token // Token under test
+	}{
+		{
+			name: "ident",
+			tok:  token.Token{Kind: token.Ident, Text: "SOME_VAR"},
+			want: "SOME_VAR",
+		},
+		{
+			name: "string",
+			tok:  token.Token{Kind: token.String, Text: `"hello"`},
+			want: "hello",
+		},
+		{
+			name: "string escapes",
+			tok:  token.Token{Kind: token.String, Text: `"Newline\n and a tab\t and a \"quote\" \$HOME \q"`},
+			want: "Newline\n and a tab\t and a \"quote\" $HOME \\q",
+		},
+		{
+			name: "empty string",
+			tok:  token.Token{Kind: token.String, Text: `""`},
+			want: "",
+		},
+		{
+			name: "multiline string",
+			tok:  token.Token{Kind: token.String, Text: `"""many lines"""`},
+			want: "many lines",
+		},
+		{
+			name: "multiline string dedented",
+			tok:  token.Token{Kind: token.String, Text: "\"\"\"\n    one\\t\n      two\n    \"\"\""},
+			want: "one\t\n  two",
+		},
+		{
+			name: "raw multiline string",
+			tok:  token.Token{Kind: token.RawString, Text: "'''\n  ${USER}\\n\n  '''"},
+			want: `${USER}\n`,
+		},
+		{
+			name: "raw string",
+			tok:  token.Token{Kind: token.RawString, Text: `'literally \n ${USER}'`},
+			want: `literally \n ${USER}`,
+		},
+		{
+			name: "raw string doubled quote",
+			tok:  token.Token{Kind: token.RawString, Text: `'it''s ''quoted'''`},
+			want: `it's 'quoted'`,
+		},
+		{
+			name: "backtick string doubled quote",
+			tok:  token.Token{Kind: token.RawString, Text: "`it''s`"},
+			want: `it''s`,
+		},
+		{
+			name: "bare var interp",
+			tok:  token.Token{Kind: token.VarInterp, Text: "$USER"},
+			want: "USER",
+		},
+		{
+			name: "bracketed var interp",
+			tok:  token.Token{Kind: token.VarInterp, Text: "${USER}"},
+			want: "USER",
+		},
+		{
+			name: "cmd interp",
+			tok:  token.Token{Kind: token.CmdInterp, Text: "$(op read op://vault/item)"},
+			want: "op read op://vault/item",
+		},
+		{
+			name: "comment",
+			tok:  token.Token{Kind: token.Comment, Text: "#   A comment  "},
+			want: "A comment",
+		},
+		{
+			name: "inline comment",
+			tok:  token.Token{Kind: token.InlineComment, Text: "# Inline"},
+			want: "Inline",
+		},
+		{
+			name: "newline",
+			tok:  token.Token{Kind: token.Newline, Text: "\r\n"},
+			want: "\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.Equal(t, tt.tok.Value(), tt.want)
+		})
+	}
+}
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		name string // Name of the test case
+		src  string // Contents of the multiline string
+		want string // Expected dedented contents
+	}{
+		{name: "empty", src: "", want: ""},
+		{name: "single line", src: "  hello  ", want: "hello  "},
+		{name: "surrounding newlines", src: "\nhello\n", want: "hello"},
+		{name: "only the first newline", src: "\n\nhello\n\n", want: "\nhello\n"},
+		{name: "indented closing quotes", src: "\n    hello\n    ", want: "hello"},
+		{name: "common indent", src: "\n    one\n      two\n    three\n", want: "one\n  two\nthree"},
+		{name: "blank lines ignored", src: "\n    one\n\n  \n    two\n", want: "one\n\n\ntwo"},
+		{name: "tabs", src: "\n\tone\n\t\ttwo\n", want: "one\n\ttwo"},
+		{name: "mixed tabs and spaces", src: "\n\tone\n    two\n", want: "\tone\n    two"},
+		{name: "first line not indented", src: "one\n    two", want: "one\n    two"},
+		{name: "crlf", src: "\r\n  one\r\n    two\r\n  ", want: "one\r\n  two"},
+		{
+			name: "pem",
+			src:  "\n    -----BEGIN CERTIFICATE-----\n    MIIBszCCAVmgAwIBAgIU\n    -----END CERTIFICATE-----\n    ",
+			want: "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n-----END CERTIFICATE-----",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.Equal(t, token.Dedent(tt.src), tt.want)
+		})
+	}
+}
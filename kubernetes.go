@@ -0,0 +1,166 @@
+package dotenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kubernetes naming limits, see https://kubernetes.io/docs/concepts/overview/working-with-objects/names.
+const (
+	maxObjectName = 253 // Longest name of a ConfigMap or Secret, a DNS subdomain
+	maxNamespace  = 63  // Longest namespace, a DNS label
+)
+
+var (
+	// subdomain matches a DNS subdomain name (RFC 1123), used for object names.
+	subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+	// label matches a DNS label name (RFC 1123), used for namespaces.
+	label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+	// dataKey matches a valid key in the data of a ConfigMap or Secret.
+	dataKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// Manifest describes the Kubernetes objects generated by [Kubernetes].
+type Manifest struct {
+	Labels     map[string]string // Labels for both objects
+	Name       string            // Name of the ConfigMap, and the Secret unless SecretName is set
+	SecretName string            // Name of the Secret, if different to the ConfigMap
+	Namespace  string            // Namespace of both objects, "" to leave it to kubectl
+}
+
+// Kubernetes renders env as Kubernetes manifests: a ConfigMap holding the ordinary
+// variables and a Secret holding the secret ones (see [SecretKeys]), base64 encoded.
+//
+// The result is a YAML stream of the ConfigMap then the Secret, either is left out if
+// it would be empty. Inline comments on variables are kept as comments on their values.
+//
+// The names must be valid Kubernetes object names, and every variable name must be a
+// valid key in a ConfigMap or Secret.
+//
+//	env, err := dotenv.Read(".env", dotenv.SecretKeys("DATABASE_URL"))
+//	manifest, err := dotenv.Kubernetes(env, dotenv.Manifest{Name: "app", Namespace: "prod"})
+func Kubernetes(env Env, manifest Manifest) ([]byte, error) {
+	if manifest.SecretName == "" {
+		manifest.SecretName = manifest.Name
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	config := yamlMapping()
+	secrets := yamlMapping()
+
+	for key, value := range env.All() {
+		if !dataKey.MatchString(key) {
+			return nil, fmt.Errorf(
+				"%s is not a valid ConfigMap or Secret key, they may only contain letters, digits, '-', '_' and '.'",
+				key,
+			)
+		}
+
+		data := config
+		if env.IsSecret(key) {
+			data = secrets
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+
+		data.Content = append(data.Content, yamlString(key), yamlString(value))
+		data.Content[len(data.Content)-1].LineComment = env.Comment(key)
+	}
+
+	buf := &bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(yamlIndent)
+
+	if len(config.Content) != 0 || len(secrets.Content) == 0 {
+		if err := encoder.Encode(manifest.object("ConfigMap", manifest.Name, config)); err != nil {
+			return nil, fmt.Errorf("could not encode ConfigMap: %w", err)
+		}
+	}
+
+	if len(secrets.Content) != 0 {
+		if err := encoder.Encode(manifest.object("Secret", manifest.SecretName, secrets)); err != nil {
+			return nil, fmt.Errorf("could not encode Secret: %w", err)
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("could not encode manifest: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// validate checks the names in the manifest are valid Kubernetes names.
+func (m Manifest) validate() error {
+	if m.Name == "" {
+		return errors.New("a Kubernetes manifest needs a name")
+	}
+
+	for _, name := range []string{m.Name, m.SecretName} {
+		if len(name) > maxObjectName || !subdomain.MatchString(name) {
+			return fmt.Errorf("invalid name %q, names must be lower case letters, digits, '-' and '.'", name)
+		}
+	}
+
+	if m.Namespace != "" && (len(m.Namespace) > maxNamespace || !label.MatchString(m.Namespace)) {
+		return fmt.Errorf("invalid namespace %q, namespaces must be lower case letters, digits and '-'", m.Namespace)
+	}
+
+	return nil
+}
+
+// object returns the YAML for a Kubernetes object of the given kind and name, with data.
+func (m Manifest) object(kind, name string, data *yaml.Node) *yaml.Node {
+	metadata := yamlMapping()
+	metadata.Content = append(metadata.Content, yamlString("name"), yamlString(name))
+
+	if m.Namespace != "" {
+		metadata.Content = append(metadata.Content, yamlString("namespace"), yamlString(m.Namespace))
+	}
+
+	if len(m.Labels) != 0 {
+		labels := yamlMapping()
+		for _, key := range slices.Sorted(maps.Keys(m.Labels)) {
+			labels.Content = append(labels.Content, yamlString(key), yamlString(m.Labels[key]))
+		}
+
+		metadata.Content = append(metadata.Content, yamlString("labels"), labels)
+	}
+
+	object := yamlMapping()
+	object.Content = append(object.Content,
+		yamlString("apiVersion"), yamlString("v1"),
+		yamlString("kind"), yamlString(kind),
+		yamlString("metadata"), metadata,
+	)
+
+	if kind == "Secret" {
+		object.Content = append(object.Content, yamlString("type"), yamlString("Opaque"))
+	}
+
+	object.Content = append(object.Content, yamlString("data"), data)
+
+	return object
+}
+
+// yamlString returns a YAML node for the string s.
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+// yamlMapping returns an empty YAML mapping node.
+func yamlMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
@@ -0,0 +1,146 @@
+package dotenv_test
+
+import (
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestKubernetes(t *testing.T) {
+	src := `PORT=8080 # The port
+LOG_LEVEL=info
+API_TOKEN=abc123
+# @secret
+DATABASE_URL=postgres://me:hunter2@db/app
+`
+
+	env, err := dotenv.Parse("test.env", []byte(src))
+	test.Ok(t, err)
+
+	tests := []struct {
+		name     string          // Name of the test case
+		src      string          // Source of the variables, if not src
+		want     string          // Expected manifest
+		manifest dotenv.Manifest // Description of the objects to generate
+	}{
+		{
+			name:     "both",
+			manifest: dotenv.Manifest{Name: "app", Namespace: "prod", Labels: map[string]string{"tier": "web", "app": "demo"}},
+			want: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: demo
+    tier: web
+data:
+  PORT: "8080" # The port
+  LOG_LEVEL: info
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: demo
+    tier: web
+type: Opaque
+data:
+  API_TOKEN: YWJjMTIz
+  DATABASE_URL: cG9zdGdyZXM6Ly9tZTpodW50ZXIyQGRiL2FwcA==
+`,
+		},
+		{
+			name:     "secret name",
+			src:      "API_TOKEN=abc123\n",
+			manifest: dotenv.Manifest{Name: "app", SecretName: "app-secrets"},
+			want: `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secrets
+type: Opaque
+data:
+  API_TOKEN: YWJjMTIz
+`,
+		},
+		{
+			name:     "empty",
+			src:      "# Nothing here\n",
+			manifest: dotenv.Manifest{Name: "app"},
+			want: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data: {}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := env
+			if tt.src != "" {
+				vars, err = dotenv.Parse("test.env", []byte(tt.src))
+				test.Ok(t, err)
+			}
+
+			got, err := dotenv.Kubernetes(vars, tt.manifest)
+			test.Ok(t, err)
+			test.Diff(t, string(got), tt.want)
+		})
+	}
+}
+
+func TestKubernetesErrors(t *testing.T) {
+	env, err := dotenv.Parse("test.env", []byte("PORT=8080\n"))
+	test.Ok(t, err)
+
+	tests := []struct {
+		name     string          // Name of the test case
+		err      string          // Expected error
+		manifest dotenv.Manifest // Description of the objects to generate
+	}{
+		{
+			name:     "no name",
+			manifest: dotenv.Manifest{},
+			err:      "a Kubernetes manifest needs a name",
+		},
+		{
+			name:     "bad name",
+			manifest: dotenv.Manifest{Name: "My_App"},
+			err:      `invalid name "My_App", names must be lower case letters, digits, '-' and '.'`,
+		},
+		{
+			name:     "bad secret name",
+			manifest: dotenv.Manifest{Name: "app", SecretName: "-secrets"},
+			err:      `invalid name "-secrets", names must be lower case letters, digits, '-' and '.'`,
+		},
+		{
+			name:     "bad namespace",
+			manifest: dotenv.Manifest{Name: "app", Namespace: "prod.eu"},
+			err:      `invalid namespace "prod.eu", namespaces must be lower case letters, digits and '-'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := dotenv.Kubernetes(env, tt.manifest)
+			test.Err(t, got)
+			test.Equal(t, got.Error(), tt.err)
+		})
+	}
+
+	env, err = dotenv.Unmarshal("test.json", []byte(`{"MY KEY": "value"}`), dotenv.FormatJSON)
+	test.Ok(t, err)
+
+	_, err = dotenv.Kubernetes(env, dotenv.Manifest{Name: "app"})
+	test.Err(t, err)
+	test.Equal(
+		t,
+		err.Error(),
+		"MY KEY is not a valid ConfigMap or Secret key, they may only contain letters, digits, '-', '_' and '.'",
+	)
+}
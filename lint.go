@@ -0,0 +1,79 @@
+package dotenv
+
+import (
+	"strings"
+
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/token"
+)
+
+// Position is a position in a .env file, used to report where errors and
+// warnings occurred.
+type Position = syntax.Position
+
+// Warning is a problem in a .env file that is not a syntax error but is likely
+// to be a mistake.
+type Warning struct {
+	Msg string   // Description of the problem
+	Pos Position // Where in the source the problem is
+}
+
+// String implements [fmt.Stringer] for a [Warning].
+func (w Warning) String() string {
+	return w.Pos.String() + ": " + w.Msg
+}
+
+// Lint parses the .env source src and checks it for likely mistakes, returning
+// any warnings in source order.
+//
+// Lint does not evaluate src so interpolation and command substitution are not
+// performed. A syntax error in src is returned as the error.
+//
+// The checks are:
+//
+//   - A '#' inside an unquoted value: the '#' only starts a comment when preceded
+//     by whitespace so e.g. 'KEY=abc#def' has the value "abc#def", quoting the value
+//     makes the intent clear
+func Lint(name string, src []byte, options ...Option) ([]Warning, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := parseFile(name, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []Warning
+	for _, assignment := range file.Assignments() {
+		warnings = append(warnings, lintHash(name, src, assignment)...)
+	}
+
+	return warnings, nil
+}
+
+// lintHash warns about any '#' in the unquoted parts of an assignment's value.
+func lintHash(name string, src []byte, assignment *ast.Assignment) []Warning {
+	var warnings []Warning
+	for _, part := range assignment.Value {
+		if !part.Is(token.Ident, token.String) || strings.HasPrefix(part.Text, `"`) {
+			continue
+		}
+
+		index := strings.IndexByte(part.Text, '#')
+		if index == -1 {
+			continue
+		}
+
+		start := part.Start + index
+		warnings = append(warnings, Warning{
+			Pos: syntax.Locate(name, src, start, start+1),
+			Msg: "'#' in an unquoted value is part of the value of " + assignment.Key.Text +
+				", not a comment; quote the value or put whitespace before the '#'",
+		})
+	}
+
+	return warnings
+}
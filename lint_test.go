@@ -0,0 +1,56 @@
+package dotenv_test
+
+import (
+	"slices"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string   // Name of the test case
+		src  string   // Source text to lint
+		want []string // Expected warnings, formatted with String
+	}{
+		{
+			name: "clean",
+			src:  "# A comment\nKEY=value # Inline\nQUOTED=\"abc#def\"\nRAW='abc#def'\n",
+			want: nil,
+		},
+		{
+			name: "hash in bare value",
+			src:  "A=1\nKEY=abc#def\n",
+			want: []string{
+				`lint.env:2:8-9: '#' in an unquoted value is part of the value of KEY, not a comment; quote the value or put whitespace before the '#'`,
+			},
+		},
+		{
+			name: "hash at start of value",
+			src:  "COLOUR=#fff\n",
+			want: []string{
+				`lint.env:1:8-9: '#' in an unquoted value is part of the value of COLOUR, not a comment; quote the value or put whitespace before the '#'`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := dotenv.Lint("lint.env", []byte(tt.src))
+			test.Ok(t, err)
+
+			var got []string
+			for _, warning := range warnings {
+				got = append(got, warning.String())
+			}
+
+			test.EqualFunc(t, got, tt.want, slices.Equal)
+		})
+	}
+}
+
+func TestLintSyntaxError(t *testing.T) {
+	_, err := dotenv.Lint("bad.env", []byte("A B\n"))
+	test.Err(t, err)
+}
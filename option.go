@@ -0,0 +1,255 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// config holds the configuration for parsing and loading .env files.
+type config struct {
+	decrypter   Decrypter           // Decrypts encrypted values, if set
+	resolvers   map[string]Resolver // Resolve references to values, by URL scheme
+	environment Environment         // Where variables are looked up and loaded into
+	placeholder string              // Replaces the values removed by Example
+	separator   string              // Splits keys into nested objects when converting, "" to keep them flat
+	files       []string            // Files to load, in order
+	secrets     []string            // Extra glob patterns matching the names of secret variables
+	poll        time.Duration       // How often a Watcher polls for changes, 0 to use notifications
+	dialect     Dialect             // The dialect of .env syntax to use
+	overwrite   bool                // Whether to overwrite variables already present in the environment
+	literal     bool                // Whether values are taken as written, without expanding them
+	nounset     bool                // Whether ExpandTemplate fails on undefined variables
+	spaces      bool                // Whether unquoted values may contain spaces, running to the end of the line
+}
+
+// newConfig builds a config from a set of options.
+func newConfig(options ...Option) (config, error) {
+	var cfg config
+	for _, option := range options {
+		if err := option.apply(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+
+	if cfg.environment == nil {
+		cfg.environment = OS()
+	}
+
+	return cfg, nil
+}
+
+// Option is a configuration option for parsing and loading .env files.
+type Option interface {
+	// Apply the option to the config, returning an error if the option
+	// cannot be applied for whatever reason.
+	apply(cfg *config) error
+}
+
+// option is a function adapter implementing the Option interface, analogous
+// to how http.HandlerFunc implements the Handler interface.
+type option func(cfg *config) error
+
+// apply applies the option, implementing the Option interface for the option
+// function adapter.
+func (o option) apply(cfg *config) error {
+	return o(cfg)
+}
+
+// File is an [Option] that adds a file to be loaded by [Load].
+//
+// It may be passed multiple times, files are loaded in the order they are given.
+// If no files are given, [Load] loads [DefaultFile].
+//
+// Passing an empty path is an error.
+//
+//	err := dotenv.Load(dotenv.File(".env"), dotenv.File(".env.local"))
+func File(path string) Option {
+	f := func(cfg *config) error {
+		if path == "" {
+			return errors.New("cannot load a file with an empty path")
+		}
+
+		cfg.files = append(cfg.files, path)
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// Overwrite is an [Option] that makes [Load] overwrite variables that are already
+// set in the process environment.
+//
+// By default, existing variables take precedence over those in .env files.
+func Overwrite() Option {
+	f := func(cfg *config) error {
+		cfg.overwrite = true
+		return nil
+	}
+
+	return option(f)
+}
+
+// AllowSpaces is an [Option] that lets unquoted values contain whitespace, taking the
+// rest of the line as the value (less any trailing whitespace and inline comment).
+//
+// It is useful for legacy files containing lines like 'GREETING=hello world', which
+// are otherwise a syntax error in the [Strict] and [POSIXShell] dialects. The other
+// dialects already behave this way.
+func AllowSpaces() Option {
+	f := func(cfg *config) error {
+		cfg.spaces = true
+		return nil
+	}
+
+	return option(f)
+}
+
+// SecretKeys is an [Option] that adds glob patterns (as in [path.Match]) matching the names
+// of variables whose values are secret, in addition to the defaults: *_KEY, *_TOKEN,
+// *_PASSWORD and *_SECRET. Matching is case insensitive.
+//
+// A variable can also be marked secret by "@secret" in its inline comment or in the
+// comments directly above it.
+//
+// The values of secrets are redacted from errors and when an [Env] is formatted or
+// logged, so that they don't end up in e.g. CI logs.
+//
+//	env, err := dotenv.Read(".env", dotenv.SecretKeys("DATABASE_URL", "*_DSN"))
+func SecretKeys(patterns ...string) Option {
+	f := func(cfg *config) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("bad secret pattern %q: %w", pattern, err)
+			}
+		}
+
+		cfg.secrets = append(cfg.secrets, patterns...)
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// Placeholder is an [Option] setting the value [Example] puts in place of the values
+// it removes, rather than leaving them empty.
+//
+//	src, err := dotenv.Example(".env", src, dotenv.Placeholder("changeme"))
+func Placeholder(text string) Option {
+	f := func(cfg *config) error {
+		cfg.placeholder = text
+		return nil
+	}
+
+	return option(f)
+}
+
+// Poll is an [Option] that makes a [Watcher] poll its files for changes at the given
+// interval, rather than relying on notifications from the operating system. This can
+// be useful on network filesystems, where notifications are unreliable.
+//
+//	w, err := dotenv.Watch(dotenv.Poll(500 * time.Millisecond))
+func Poll(interval time.Duration) Option {
+	f := func(cfg *config) error {
+		if interval <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+
+		cfg.poll = interval
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// In is an [Option] that makes parsing and loading use env rather than the process
+// environment: interpolated variables are looked up in it, command substitutions run
+// with it and [Load] sets variables in it.
+//
+// Passing nil is an error.
+//
+//	env := dotenv.Memory(nil)
+//	err := dotenv.Load(dotenv.In(env))
+func In(env Environment) Option {
+	f := func(cfg *config) error {
+		if env == nil {
+			return errors.New("cannot use a nil Environment")
+		}
+
+		cfg.environment = env
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// NoUnset is an [Option] that makes [ExpandTemplate] fail on a reference to an undefined
+// variable, like 'set -u' in a shell, rather than replacing it with an empty string.
+// References with a default e.g. ${VAR:-default} are still allowed.
+//
+//	out, err := dotenv.ExpandTemplate(file, env, dotenv.NoUnset())
+func NoUnset() Option {
+	f := func(cfg *config) error {
+		cfg.nounset = true
+		return nil
+	}
+
+	return option(f)
+}
+
+// Literal is an [Option] that takes values as they are written, with quotes removed and
+// escape sequences decoded but nothing expanded. No commands are run, undefined variables
+// are not an error and nothing is decrypted or resolved.
+//
+// Values that use interpolation or command substitution are kept exactly as written,
+// quotes and all, e.g. 'URL="${HOST}:${PORT}"' gives a URL of "\"${HOST}:${PORT}\"". This
+// suits comparing files, where what matters is what the file says.
+//
+//	env, err := dotenv.Read(".env", dotenv.Literal())
+func Literal() Option {
+	f := func(cfg *config) error {
+		cfg.literal = true
+		return nil
+	}
+
+	return option(f)
+}
+
+// Nest is an [Option] that makes [Marshal] split keys on separator into nested objects,
+// lower casing them, and [Unmarshal] flatten nested objects by joining their keys with
+// separator and upper casing the result. With a separator of "__", APP__DB__HOST becomes
+// app.db.host and back again.
+//
+// Passing an empty separator is an error.
+//
+//	data, err := dotenv.Marshal(env, dotenv.FormatYAML, dotenv.Nest("__"))
+func Nest(separator string) Option {
+	f := func(cfg *config) error {
+		if separator == "" {
+			return errors.New("cannot nest with an empty separator")
+		}
+
+		cfg.separator = separator
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// apply implements [Option] for a [Dialect], selecting the rules used to parse
+// and evaluate .env files.
+func (d Dialect) apply(cfg *config) error {
+	if d < Strict || d > POSIXShell {
+		return fmt.Errorf("invalid dialect: %s", d)
+	}
+
+	cfg.dialect = d
+
+	return nil
+}
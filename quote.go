@@ -0,0 +1,104 @@
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quote returns value formatted for use as a value in a .env file such that it
+// evaluates back to value exactly.
+//
+// Values made up only of characters with no special meaning are left bare, anything
+// else is double quoted with the necessary escape sequences.
+func quote(value string) string {
+	if isBare(value) {
+		return value
+	}
+
+	b := &strings.Builder{}
+	b.Grow(len(value) + len(`""`))
+	b.WriteByte('"')
+
+	for i := range len(value) {
+		switch char := value[i]; char {
+		case '\\', '"', '$':
+			b.WriteByte('\\')
+			b.WriteByte(char)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(char)
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// isBare reports whether value can be written without quotes.
+func isBare(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for i := range len(value) {
+		char := value[i]
+
+		isSafe := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') ||
+			strings.IndexByte("-_./:@,+=%[]", char) != -1
+		if !isSafe {
+			return false
+		}
+	}
+
+	return true
+}
+
+// quoteFor returns value formatted for use as the value of key in a .env file written
+// in dialect, such that it evaluates back to value exactly in that dialect.
+//
+// Values are left bare where [quote] would, otherwise each dialect uses whichever quotes
+// need the least escaping. It is an error if the dialect has no way to write value.
+func quoteFor(dialect Dialect, key, value string) (string, error) {
+	switch dialect {
+	case DockerEnvFile:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("the value of %s spans multiple lines, which the docker dialect can't represent", key)
+		}
+
+		return value, nil
+	case PythonDotenv:
+		if isBare(value) {
+			return value, nil
+		}
+
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'", nil
+	case NodeDotenv:
+		if isBare(value) {
+			return value, nil
+		}
+
+		// Single quotes and backticks have no escape sequences, so use one the value lacks
+		for _, q := range []string{"'", "`"} {
+			if !strings.Contains(value, q) {
+				return q + value + q, nil
+			}
+		}
+
+		return "", fmt.Errorf("the value of %s contains both ' and `, which the node dialect can't represent", key)
+	case POSIXShell:
+		if isBare(value) {
+			return value, nil
+		}
+
+		return posixQuote(value), nil
+	default:
+		// Strict and compose share double quoted escape sequences
+		return quote(value), nil
+	}
+}
@@ -0,0 +1,164 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// urlScheme matches a valid URL scheme (RFC 3986).
+var urlScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+// Resolver resolves references to values kept somewhere else, such as a file or a
+// secret manager, so they needn't be written in the .env file.
+//
+// Resolvers are registered for a URL scheme with [Register], a reference is then either
+// a whole unquoted value that is a URL with that scheme e.g. 'API_KEY=op://vault/item/field',
+// or part of a value written ${ref:scheme://...} e.g. "postgres://me:${ref:file:///run/secrets/db}@db".
+// A quoted value, or one built by interpolation, is taken as it is.
+//
+// Implementations needn't be safe for concurrent use, references are resolved one at a
+// time as a file is loaded. In tests, a [ResolverFunc] makes a convenient fake.
+type Resolver interface {
+	// Resolve returns the value ref refers to.
+	Resolve(ref *url.URL) (string, error)
+}
+
+// ResolverFunc is a function adapter implementing [Resolver], analogous to how
+// [net/http.HandlerFunc] implements [net/http.Handler].
+//
+//	fake := dotenv.ResolverFunc(func(ref *url.URL) (string, error) {
+//		return "secret", nil
+//	})
+type ResolverFunc func(ref *url.URL) (string, error)
+
+// Resolve implements [Resolver] for a [ResolverFunc], by calling it.
+func (f ResolverFunc) Resolve(ref *url.URL) (string, error) {
+	return f(ref)
+}
+
+// FileResolver returns a [Resolver] for file:// references, which resolve to the contents
+// of the file with any trailing newlines removed. This suits the secrets docker and
+// Kubernetes mount as files.
+//
+// The path is absolute as in file:///run/secrets/db, or relative to the working directory
+// as in file:secrets/db.
+//
+//	env, err := dotenv.Read(".env", dotenv.Register("file", dotenv.FileResolver()))
+func FileResolver() Resolver {
+	f := func(ref *url.URL) (string, error) {
+		path := ref.Opaque
+		if path == "" {
+			path = ref.Host + ref.Path
+		}
+
+		if path == "" {
+			return "", errors.New("file reference has no path")
+		}
+
+		contents, err := os.ReadFile(filepath.FromSlash(path)) //nolint:gosec // Reading the file the user referred to is the point
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(contents), "\r\n"), nil
+	}
+
+	return ResolverFunc(f)
+}
+
+// EnvironmentResolver returns a [Resolver] for references like env://NAME, which resolve
+// to the value of the variable NAME in env. An unset variable is an error.
+//
+//	env, err := dotenv.Read(".env", dotenv.Register("env", dotenv.EnvironmentResolver(dotenv.OS())))
+func EnvironmentResolver(env Environment) Resolver {
+	f := func(ref *url.URL) (string, error) {
+		name := strings.Trim(ref.Opaque+ref.Host+ref.Path, "/")
+		if name == "" {
+			return "", errors.New("environment reference has no variable name")
+		}
+
+		value, ok := env.Lookup(name)
+		if !ok {
+			return "", fmt.Errorf("%s is not set", name)
+		}
+
+		return value, nil
+	}
+
+	return ResolverFunc(f)
+}
+
+// Register is an [Option] that resolves references with the given scheme (e.g. "op" for
+// op://vault/item/field) using r, see [Resolver]. Registering the same scheme again
+// replaces its resolver.
+//
+// Nothing is resolved without this option, so values that happen to look like URLs are
+// left alone. Variables whose values came from a resolver are secret.
+//
+// Passing an invalid scheme or a nil Resolver is an error.
+//
+//	env, err := dotenv.Read(".env", dotenv.Register("file", dotenv.FileResolver()), dotenv.Register("op", vault))
+func Register(scheme string, r Resolver) Option {
+	f := func(cfg *config) error {
+		if !isScheme(scheme) {
+			return fmt.Errorf("cannot register a resolver for invalid scheme %q", scheme)
+		}
+
+		if r == nil {
+			return fmt.Errorf("cannot register a nil Resolver for %s", scheme)
+		}
+
+		if cfg.resolvers == nil {
+			cfg.resolvers = make(map[string]Resolver)
+		}
+
+		cfg.resolvers[strings.ToLower(scheme)] = r
+
+		return nil
+	}
+
+	return option(f)
+}
+
+// isScheme reports whether s is a valid URL scheme.
+func isScheme(s string) bool {
+	return urlScheme.MatchString(s)
+}
+
+// resolveReference resolves ref using the resolver registered for its scheme in resolvers.
+func resolveReference(resolvers map[string]Resolver, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("bad reference %q: %w", ref, err)
+	}
+
+	resolver, ok := resolvers[u.Scheme]
+	if !ok {
+		return "", fmt.Errorf("no resolver registered for %s references, pass one with dotenv.Register", u.Scheme)
+	}
+
+	value, err := resolver.Resolve(u)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %w", ref, err)
+	}
+
+	return value, nil
+}
+
+// isReference reports whether the whole of value is a reference with a scheme that has
+// a resolver in resolvers.
+func isReference(resolvers map[string]Resolver, value string) bool {
+	name, _, ok := strings.Cut(value, ":")
+	if !ok || !isScheme(name) {
+		return false
+	}
+
+	_, registered := resolvers[strings.ToLower(name)]
+
+	return registered
+}
@@ -0,0 +1,163 @@
+package dotenv_test
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestResolvers(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	test.Ok(t, os.WriteFile(secret, []byte("hunter2\n"), 0o600))
+
+	vault := dotenv.ResolverFunc(func(ref *url.URL) (string, error) {
+		if ref.Host == "locked" {
+			return "", errors.New("vault is locked")
+		}
+
+		return "op:" + ref.Host + ref.Path, nil
+	})
+
+	environment := dotenv.Memory(map[string]string{"HOME": "/home/gopher"})
+
+	options := []dotenv.Option{
+		dotenv.In(environment),
+		dotenv.Register("file", dotenv.FileResolver()),
+		dotenv.Register("OP", vault),
+		dotenv.Register("env", dotenv.EnvironmentResolver(environment)),
+	}
+
+	tests := []struct {
+		name   string   // Name of the test case
+		src    string   // The .env source
+		want   string   // Expected variables, as by Env.String
+		err    string   // Expected error, "" if none
+		secret []string // Variables expected to be secret
+	}{
+		{
+			name:   "whole value",
+			src:    "TOKEN=op://vault/item/field\nPLAIN=op://vault/item/field # Comment\n",
+			want:   "TOKEN=[REDACTED]\nPLAIN=[REDACTED]",
+			secret: []string{"TOKEN", "PLAIN"},
+		},
+		{
+			name:   "file",
+			src:    "PASSWORD=file://" + filepath.ToSlash(secret) + "\n",
+			want:   "PASSWORD=[REDACTED]",
+			secret: []string{"PASSWORD"},
+		},
+		{
+			name:   "embedded",
+			src:    `DSN="postgres://me:${ref:file://` + filepath.ToSlash(secret) + `}@db/${ref:env://HOME}"` + "\n",
+			want:   "DSN=[REDACTED]",
+			secret: []string{"DSN"},
+		},
+		{
+			name: "unregistered",
+			src:  "URL=https://example.com\nPATH=./bin:/usr/bin\n",
+			want: "URL=https://example.com\nPATH=./bin:/usr/bin",
+		},
+		{
+			name: "quoted",
+			src:  "REF='op://vault/item/field'\nOTHER=\"op://vault/item/field\"\n",
+			want: "REF=op://vault/item/field\nOTHER=op://vault/item/field",
+		},
+		{
+			name: "interpolated",
+			src:  "SCHEME=op\nREF=${SCHEME}://vault/item/field\n",
+			want: "SCHEME=op\nREF=op://vault/item/field",
+		},
+		{
+			name: "not registered",
+			src:  "A=${ref:vault://secret}\n",
+			err:  "test.env:1:3-4: no resolver registered for vault references, pass one with dotenv.Register",
+		},
+		{
+			name: "resolver error",
+			src:  "A=1\nB=op://locked/item\n",
+			err:  "test.env:2:3-19: could not resolve op://locked/item: vault is locked",
+		},
+		{
+			name: "unset environment",
+			src:  "A=env://NOPE\n",
+			err:  "test.env:1:3-13: could not resolve env://NOPE: NOPE is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Parse("test.env", []byte(tt.src), options...)
+			if tt.err != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.err)
+
+				return
+			}
+
+			test.Ok(t, err)
+			test.Equal(t, env.String(), tt.want)
+
+			for _, key := range tt.secret {
+				test.True(t, env.IsSecret(key), test.Context("%s should be secret", key))
+			}
+		})
+	}
+}
+
+func TestResolvedValues(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	test.Ok(t, os.WriteFile(secret, []byte("hunter2\r\n\n"), 0o600))
+
+	src := "PASSWORD=file://" + filepath.ToSlash(secret) + "\nDSN=\"postgres://me:${ref:file://" + filepath.ToSlash(secret) + "}@db\"\n"
+
+	env, err := dotenv.Parse("test.env", []byte(src), dotenv.Register("file", dotenv.FileResolver()))
+	test.Ok(t, err)
+
+	password, _ := env.Get("PASSWORD")
+	test.Equal(t, password, "hunter2")
+
+	dsn, _ := env.Get("DSN")
+	test.Equal(t, dsn, "postgres://me:hunter2@db")
+
+	_, err = dotenv.Parse("test.env", []byte("A=${ref:file:///does/not/exist}\n"), dotenv.Register("file", dotenv.FileResolver()))
+	test.Err(t, err)
+	test.True(t, strings.HasPrefix(err.Error(), "test.env:1:3-4: could not resolve file:///does/not/exist: "), test.Context("got %v", err))
+
+	// Without a resolver, references are left alone
+	env, err = dotenv.Parse("test.env", []byte("PASSWORD=file:///run/secrets/db\nDATABASE_URL=file:data.db\n"))
+	test.Ok(t, err)
+
+	password, _ = env.Get("PASSWORD")
+	test.Equal(t, password, "file:///run/secrets/db")
+
+	database, _ := env.Get("DATABASE_URL")
+	test.Equal(t, database, "file:data.db")
+}
+
+func TestResolverTemplate(t *testing.T) {
+	vault := dotenv.ResolverFunc(func(ref *url.URL) (string, error) {
+		return strings.ToUpper(ref.Path), nil
+	})
+
+	got, err := dotenv.ExpandTemplate(strings.NewReader("key: ${ref:op://vault/key}"), dotenv.Env{}, dotenv.Register("op", vault))
+	test.Ok(t, err)
+	test.Equal(t, string(got), "key: /KEY")
+}
+
+func TestRegisterErrors(t *testing.T) {
+	_, err := dotenv.Parse("test.env", nil, dotenv.Register("1password", dotenv.FileResolver()))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), `cannot register a resolver for invalid scheme "1password"`)
+
+	_, err = dotenv.Parse("test.env", nil, dotenv.Register("op", nil))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "cannot register a nil Resolver for op")
+}
@@ -0,0 +1,421 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.followtheprocess.codes/dotenv/internal/secret"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+	"go.followtheprocess.codes/dotenv/internal/syntax/ast"
+	"go.followtheprocess.codes/dotenv/internal/syntax/token"
+)
+
+// SchemaFile is the conventional name of a schema file, see [ParseSchema].
+const SchemaFile = ".env.schema"
+
+// Type is the type of a variable declared in a [Schema].
+type Type int
+
+//go:generate stringer -type Type -linecomment
+const (
+	TypeString   Type = iota // string
+	TypeInt                  // int
+	TypeBool                 // bool
+	TypeURL                  // url
+	TypeDuration             // duration
+	TypeEnum                 // enum
+	TypeRegex                // regex
+)
+
+// Field is the declaration of a single variable in a [Schema].
+type Field struct {
+	re          *regexp.Regexp // Compiled Pattern, anchored to match the whole value
+	Name        string         // Name of the variable
+	Description string         // Documentation from the comments, without the annotations
+	Default     string         // Value used when the variable is not set
+	Example     string         // Value given in the schema, unevaluated, ignored by Validate
+	Pattern     string         // Regular expression a TypeRegex value must match in full
+	Values      []string       // Allowed values of a TypeEnum
+	Pos         Position       // Where the variable is declared in the schema
+	Type        Type           // Type of the value
+	Required    bool           // Whether the variable must be set to a non-empty value
+	Secret      bool           // Whether the variable is a secret
+	Typed       bool           // Whether Type was declared by @type, rather than defaulting to TypeString
+}
+
+// check checks value against the field's type, returning a description of the
+// problem if it doesn't conform, or "" if it does.
+func (f Field) check(value string) string {
+	switch f.Type {
+	case TypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an int"
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be a bool (true or false)"
+		}
+	case TypeURL:
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" {
+			return "must be a URL with a scheme"
+		}
+	case TypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "must be a duration e.g. 30s"
+		}
+	case TypeEnum:
+		if !slices.Contains(f.Values, value) {
+			return "must be one of " + strings.Join(f.Values, ", ")
+		}
+	case TypeRegex:
+		if !f.re.MatchString(value) {
+			return "must match " + f.Pattern
+		}
+	default:
+		// TypeString, anything goes
+	}
+
+	return ""
+}
+
+// Schema declares the variables an application expects, see [ParseSchema].
+type Schema struct {
+	fields []Field // The declared variables, in source order
+}
+
+// Fields returns the variables declared in the schema, in the order they were declared.
+func (s Schema) Fields() []Field {
+	return slices.Clone(s.fields)
+}
+
+// Field returns the declaration of the variable name, and reports whether it was declared.
+func (s Schema) Field(name string) (Field, bool) {
+	index := slices.IndexFunc(s.fields, func(field Field) bool { return field.Name == name })
+	if index == -1 {
+		return Field{}, false
+	}
+
+	return s.fields[index], true
+}
+
+// Apply returns a copy of env with the defaults from the schema set for any variables
+// that are not set in env.
+func (s Schema) Apply(env Env) Env {
+	applied := Env{
+		values:    maps.Clone(env.values),
+		comments:  maps.Clone(env.comments),
+		positions: maps.Clone(env.positions),
+		secrets:   maps.Clone(env.secrets),
+		keys:      slices.Clone(env.keys),
+	}
+
+	for _, field := range s.fields {
+		if _, ok := env.Get(field.Name); ok || field.Default == "" {
+			continue
+		}
+
+		set(&applied, field.Name, field.Default)
+
+		if field.Secret {
+			conceal(&applied, field.Name)
+		}
+	}
+
+	return applied
+}
+
+// ParseSchema parses a schema from the .env source src.
+//
+// A schema is an ordinary .env file, so a .env.example can double as one, with
+// each variable declared by an assignment. Its value is ignored, the variable is
+// described by the comments directly above it or inline. Comment lines starting with
+// an annotation configure the variable, other lines form its description:
+//
+//	# The port to listen on
+//	# @type int
+//	# @default 8080
+//	PORT=
+//
+//	LOG_LEVEL=info # @type enum debug info warn error
+//
+//	# @optional
+//	# @type url
+//	SENTRY_DSN=
+//
+// The annotations are:
+//
+//   - @type: one of string (the default), int, bool, url, duration, "enum" followed
+//     by the allowed values, or "regex" followed by a regular expression the whole
+//     value must match
+//   - @default: the value used when the variable is not set, the variable is then optional
+//   - @optional: the variable may be unset or empty, by default every variable is required
+//   - @secret: the variable is a secret and its value is redacted from violations
+//
+// Variables whose names match the secret patterns (see [SecretKeys]) are secret too.
+func ParseSchema(name string, src []byte, options ...Option) (Schema, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	file, err := parseFile(name, src, cfg)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	p := schemaParser{
+		name:    name,
+		src:     src,
+		secrets: secret.New(cfg.secrets...).File(file),
+	}
+
+	var (
+		schema   Schema
+		comments []*ast.Comment // The block of comments directly above the current statement
+	)
+
+	for _, statement := range file.Statements {
+		switch node := statement.(type) {
+		case *ast.Comment:
+			comments = append(comments, node)
+		case *ast.Assignment:
+			if node.Comment != nil {
+				comments = append(comments, node.Comment)
+			}
+
+			schema.fields = append(schema.fields, p.field(node, comments))
+			comments = nil
+		default:
+			comments = nil
+		}
+	}
+
+	return schema, errors.Join(p.errs...)
+}
+
+// ReadSchema reads and parses the schema file at path, see [ParseSchema].
+func ReadSchema(path string, options ...Option) (Schema, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return ParseSchema(path, src, options...)
+}
+
+// schemaParser builds the fields of a [Schema] from the assignments in a .env file.
+type schemaParser struct {
+	secrets map[*ast.Assignment]bool // The secret assignments
+	name    string                   // Name of the schema file
+	src     []byte                   // Raw source text, for error positions
+	errs    []error                  // Errors in the annotations
+}
+
+// field builds the declaration of the variable in assignment, described by comments.
+func (p *schemaParser) field(assignment *ast.Assignment, comments []*ast.Comment) Field {
+	var example strings.Builder
+	for _, part := range assignment.Value {
+		example.WriteString(part.Value())
+	}
+
+	field := Field{
+		Name:     assignment.Key.Text,
+		Example:  example.String(),
+		Pos:      p.position(assignment.Key),
+		Secret:   p.secrets[assignment],
+		Required: true,
+	}
+
+	var description []string
+
+	hasDefault := false
+	for _, comment := range comments {
+		text := comment.Token.Value()
+		if !strings.HasPrefix(text, "@") {
+			description = append(description, text)
+			continue
+		}
+
+		annotation, arg, _ := strings.Cut(text, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch annotation {
+		case "@type":
+			if err := parseType(&field, arg); err != nil {
+				p.errorf(comment.Token, "bad type for %s: %v", field.Name, err)
+			}
+
+			field.Typed = true
+		case "@default":
+			field.Default = arg
+			hasDefault = true
+		case "@optional":
+			field.Required = false
+		case secret.Annotation:
+			// Already taken into account by the classifier
+		default:
+			p.errorf(comment.Token, "unknown annotation %s on %s", annotation, field.Name)
+		}
+	}
+
+	field.Description = strings.Join(description, "\n")
+	field.Required = field.Required && !hasDefault
+
+	if field.Default != "" {
+		if problem := field.check(field.Default); problem != "" {
+			p.errorf(assignment.Key, "default for %s %s, got %q", field.Name, problem, field.Default)
+		}
+	}
+
+	return field
+}
+
+// errorf records a formatted error at the position of tok.
+func (p *schemaParser) errorf(tok token.Token, format string, a ...any) {
+	p.errs = append(p.errs, syntax.Error{Pos: p.position(tok), Msg: fmt.Sprintf(format, a...)})
+}
+
+// position calculates the source position of a token.
+func (p *schemaParser) position(tok token.Token) Position {
+	start, end := tok.Span()
+
+	return syntax.Locate(p.name, p.src, start, end)
+}
+
+// parseType parses the argument to a @type annotation into field.
+func parseType(field *Field, arg string) error {
+	kind, rest, _ := strings.Cut(arg, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch kind {
+	case "string", "":
+		field.Type = TypeString
+	case "int":
+		field.Type = TypeInt
+	case "bool":
+		field.Type = TypeBool
+	case "url":
+		field.Type = TypeURL
+	case "duration":
+		field.Type = TypeDuration
+	case "enum":
+		values := strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(values) == 0 {
+			return errors.New("enum needs at least one value")
+		}
+
+		field.Type = TypeEnum
+		field.Values = values
+	case "regex":
+		pattern, err := regexp.Compile(`^(?:` + rest + `)$`)
+		if err != nil {
+			return err
+		}
+
+		field.Type = TypeRegex
+		field.Pattern = rest
+		field.re = pattern
+	default:
+		return fmt.Errorf("unknown type %q", kind)
+	}
+
+	return nil
+}
+
+// Violation is a variable that does not conform to its declaration in a [Schema].
+type Violation struct {
+	Key string   // Name of the variable
+	Msg string   // Description of the problem
+	Pos Position // Where the value was defined, or where the variable was declared if it's missing
+}
+
+// String implements [fmt.Stringer] for a [Violation].
+func (v Violation) String() string {
+	return v.Pos.String() + ": " + v.Msg
+}
+
+// ValidationError is the error returned by [Validate], listing every violation.
+type ValidationError struct {
+	Violations []Violation // The violations, in the order the variables are declared in the schema
+}
+
+// Error implements the error interface for a [ValidationError], with one violation per line.
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		lines = append(lines, violation.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks env against schema, returning a [*ValidationError] listing every
+// variable that is missing or doesn't conform to its declared type, or nil if all is well.
+//
+// Variables in env that are not declared in the schema are ignored, as are missing
+// variables with a default; use [Schema.Apply] to fill them in. The values of secrets
+// are never included in the violations.
+func Validate(env Env, schema Schema) error {
+	var violations []Violation
+
+	for _, field := range schema.fields {
+		value, ok := env.Get(field.Name)
+		if !ok || value == "" {
+			if field.Required {
+				violations = append(violations, missing(env, field, ok))
+			}
+
+			continue
+		}
+
+		problem := field.check(value)
+		if problem == "" {
+			continue
+		}
+
+		got := strconv.Quote(value)
+		if field.Secret || env.IsSecret(field.Name) {
+			got = secret.Redacted
+		}
+
+		violations = append(violations, Violation{
+			Key: field.Name,
+			Msg: fmt.Sprintf("%s %s, got %s", field.Name, problem, got),
+			Pos: where(env, field),
+		})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations}
+}
+
+// missing returns the violation for a required field that is not set in env, or is
+// set but empty.
+func missing(env Env, field Field, set bool) Violation {
+	if !set {
+		return Violation{Key: field.Name, Msg: field.Name + " is required but not set", Pos: field.Pos}
+	}
+
+	return Violation{Key: field.Name, Msg: field.Name + " is required but empty", Pos: where(env, field)}
+}
+
+// where returns the position of the value of field in env, or of its declaration
+// if env doesn't know where it was defined.
+func where(env Env, field Field) Position {
+	if pos, ok := env.positions[field.Name]; ok {
+		return pos
+	}
+
+	return field.Pos
+}
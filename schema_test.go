@@ -0,0 +1,205 @@
+package dotenv_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+const schemaSrc = `# The port to listen on
+# @type int
+# @default 8080
+PORT=
+
+LOG_LEVEL=info # @type enum debug, info, warn, error
+
+# @optional
+# @type url
+SENTRY_DSN=
+
+# @type bool
+DEBUG=
+
+# @type duration
+TIMEOUT=
+
+# @type regex [a-z]+(-[a-z]+)*
+NAME=
+
+# @type regex [0-9]+
+API_KEY=
+
+# Anything at all
+GREETING=
+`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := dotenv.ParseSchema("test.schema", []byte(schemaSrc))
+	test.Ok(t, err)
+
+	names := make([]string, 0, len(schema.Fields()))
+	for _, field := range schema.Fields() {
+		names = append(names, field.Name)
+	}
+
+	test.EqualFunc(t, names, []string{"PORT", "LOG_LEVEL", "SENTRY_DSN", "DEBUG", "TIMEOUT", "NAME", "API_KEY", "GREETING"}, slices.Equal)
+
+	port, ok := schema.Field("PORT")
+	test.True(t, ok)
+	test.Equal(t, port.Type, dotenv.TypeInt)
+	test.Equal(t, port.Default, "8080")
+	test.Equal(t, port.Description, "The port to listen on")
+	test.False(t, port.Required, test.Context("a field with a default is optional"))
+	test.Equal(t, port.Pos.String(), "test.schema:4:1-5")
+
+	level, _ := schema.Field("LOG_LEVEL")
+	test.Equal(t, level.Type, dotenv.TypeEnum)
+	test.EqualFunc(t, level.Values, []string{"debug", "info", "warn", "error"}, slices.Equal)
+	test.True(t, level.Required)
+
+	dsn, _ := schema.Field("SENTRY_DSN")
+	test.Equal(t, dsn.Type, dotenv.TypeURL)
+	test.False(t, dsn.Required)
+
+	key, _ := schema.Field("API_KEY")
+	test.True(t, key.Secret)
+
+	greeting, _ := schema.Field("GREETING")
+	test.Equal(t, greeting.Type, dotenv.TypeString)
+	test.Equal(t, greeting.Description, "Anything at all")
+
+	_, ok = schema.Field("NOPE")
+	test.False(t, ok)
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name    string // Name of the test case
+		src     string // Schema source
+		wantErr string // Expected error
+	}{
+		{
+			name:    "unknown type",
+			src:     "# @type float\nA=\n",
+			wantErr: `bad.schema:1:1-14: bad type for A: unknown type "float"`,
+		},
+		{
+			name:    "empty enum",
+			src:     "A= # @type enum\n",
+			wantErr: "bad.schema:1:4-16: bad type for A: enum needs at least one value",
+		},
+		{
+			name:    "bad regex",
+			src:     "# @type regex (\nA=\n",
+			wantErr: "bad.schema:1:1-16: bad type for A: error parsing regexp: missing closing ): `^(?:()$`",
+		},
+		{
+			name:    "unknown annotation",
+			src:     "# @requird\nA=\n",
+			wantErr: "bad.schema:1:1-11: unknown annotation @requird on A",
+		},
+		{
+			name:    "bad default",
+			src:     "# @type int\n# @default lots\nA=\n",
+			wantErr: `bad.schema:3:1-2: default for A must be an int, got "lots"`,
+		},
+		{
+			name:    "syntax error",
+			src:     "A B\n",
+			wantErr: `bad.schema:1:3-4: expected '=', got Ident`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dotenv.ParseSchema("bad.schema", []byte(tt.src))
+			test.Err(t, err)
+			test.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := dotenv.ParseSchema("test.schema", []byte(schemaSrc))
+	test.Ok(t, err)
+
+	tests := []struct {
+		name string   // Name of the test case
+		src  string   // .env source to validate
+		want []string // Expected violations
+	}{
+		{
+			name: "valid",
+			src:  "LOG_LEVEL=warn\nDEBUG=true\nTIMEOUT=1m30s\nNAME=my-app\nAPI_KEY=1234\nGREETING='hello there'\nSENTRY_DSN=https://sentry.io/1\n",
+			want: nil,
+		},
+		{
+			name: "missing",
+			src:  "LOG_LEVEL=warn\nDEBUG=\n",
+			want: []string{
+				".env:2:1-7: DEBUG is required but empty",
+				"test.schema:16:1-8: TIMEOUT is required but not set",
+				"test.schema:19:1-5: NAME is required but not set",
+				"test.schema:22:1-8: API_KEY is required but not set",
+				"test.schema:25:1-9: GREETING is required but not set",
+			},
+		},
+		{
+			name: "malformed",
+			src: "PORT=http\nLOG_LEVEL=verbose\nSENTRY_DSN=nope\nDEBUG=maybe\nTIMEOUT=10\n" +
+				"NAME=My-App\nAPI_KEY=abcdef\nGREETING=hi\n",
+			want: []string{
+				`.env:1:6-10: PORT must be an int, got "http"`,
+				`.env:2:11-18: LOG_LEVEL must be one of debug, info, warn, error, got "verbose"`,
+				`.env:3:12-16: SENTRY_DSN must be a URL with a scheme, got "nope"`,
+				`.env:4:7-12: DEBUG must be a bool (true or false), got "maybe"`,
+				`.env:5:9-11: TIMEOUT must be a duration e.g. 30s, got "10"`,
+				`.env:6:6-12: NAME must match [a-z]+(-[a-z]+)*, got "My-App"`,
+				`.env:7:9-15: API_KEY must match [0-9]+, got [REDACTED]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := dotenv.Parse(".env", []byte(tt.src))
+			test.Ok(t, err)
+
+			err = dotenv.Validate(env, schema)
+			if tt.want == nil {
+				test.Ok(t, err)
+				return
+			}
+
+			var validationErr *dotenv.ValidationError
+			test.True(t, errors.As(err, &validationErr))
+
+			got := make([]string, 0, len(validationErr.Violations))
+			for _, violation := range validationErr.Violations {
+				got = append(got, violation.String())
+			}
+
+			test.EqualFunc(t, got, tt.want, slices.Equal)
+		})
+	}
+}
+
+func TestSchemaApply(t *testing.T) {
+	schema, err := dotenv.ParseSchema("test.schema", []byte(schemaSrc))
+	test.Ok(t, err)
+
+	env, err := dotenv.Parse(".env", []byte("LOG_LEVEL=warn\n"))
+	test.Ok(t, err)
+
+	applied := schema.Apply(env)
+
+	port, ok := applied.Get("PORT")
+	test.True(t, ok)
+	test.Equal(t, port, "8080")
+
+	_, ok = env.Get("PORT")
+	test.False(t, ok, test.Context("Apply should not modify the original"))
+}
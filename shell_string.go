@@ -0,0 +1,28 @@
+// Code generated by "stringer -type Shell -linecomment"; DO NOT EDIT.
+
+package dotenv
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Bash-0]
+	_ = x[Zsh-1]
+	_ = x[Fish-2]
+	_ = x[PowerShell-3]
+	_ = x[Nushell-4]
+}
+
+const _Shell_name = "bashzshfishpowershellnushell"
+
+var _Shell_index = [...]uint8{0, 4, 7, 11, 21, 28}
+
+func (i Shell) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Shell_index)-1 {
+		return "Shell(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Shell_name[_Shell_index[idx]:_Shell_index[idx+1]]
+}
@@ -0,0 +1,75 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"go.followtheprocess.codes/dotenv/internal/expand"
+	"go.followtheprocess.codes/dotenv/internal/syntax"
+)
+
+// templateName is the name of a template in errors, when its reader doesn't have one.
+const templateName = "template"
+
+// ExpandTemplate reads a template from r, such as an nginx.conf or JSON config, and
+// replaces the references to variables in it with their values, in the same way as
+// interpolation in a .env file.
+//
+// Variables are looked up in env, then the process environment (or the [Environment]
+// given by [In]). Only the braced forms are expanded e.g. ${VAR} and ${VAR:-default},
+// so that '$' has its usual meaning in the template; $VAR, backslashes and $(command)
+// are left as they are. References like ${ref:file:///run/secrets/db} are resolved by
+// the resolvers given with [Register]. "$$" is a literal '$', so $${VAR} can be written
+// to keep a reference in the output.
+//
+// Undefined variables are replaced by an empty string, pass [NoUnset] to make them an
+// error instead. Errors have the position in the template they occurred at, named by
+// the reader's Name method if it has one (as an [*os.File] does).
+//
+//	out, err := dotenv.ExpandTemplate(file, env, dotenv.NoUnset())
+func ExpandTemplate(r io.Reader, env Env, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := templateName
+	if named, ok := r.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value, ok := env.Get(key); ok {
+			return value, true
+		}
+
+		return cfg.environment.Lookup(key)
+	}
+
+	expanded, err := expand.Expand(string(src), expand.Config{
+		Lookup:       lookup,
+		Reference:    func(ref string) (string, error) { return resolveReference(cfg.resolvers, ref) },
+		Strict:       cfg.nounset,
+		BracedOnly:   true,
+		DollarEscape: true,
+	})
+	if err != nil {
+		var expandErr expand.Error
+		if !errors.As(err, &expandErr) {
+			return nil, err
+		}
+
+		return nil, syntax.Error{
+			Pos: syntax.Locate(name, src, expandErr.Offset, expandErr.Offset),
+			Msg: expandErr.Msg,
+		}
+	}
+
+	return []byte(expanded), nil
+}
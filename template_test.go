@@ -0,0 +1,105 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	env, err := dotenv.Parse("test.env", []byte("HOST=example.com\nPORT=8080\nEMPTY=\n"))
+	test.Ok(t, err)
+
+	environment := dotenv.Memory(map[string]string{"USER": "gopher", "PORT": "9090"})
+
+	tests := []struct {
+		name     string          // Name of the test case
+		template string          // The template to expand
+		want     string          // Expected output
+		err      string          // Expected error, "" if none
+		options  []dotenv.Option // Options to pass to ExpandTemplate
+	}{
+		{
+			name:     "nginx",
+			template: "server {\n  listen ${PORT};\n  server_name ${HOST};\n  return 301 https://$host$request_uri;\n}\n",
+			want:     "server {\n  listen 8080;\n  server_name example.com;\n  return 301 https://$host$request_uri;\n}\n",
+		},
+		{
+			name:     "json",
+			template: `{"user": "${USER}", "path": "C:\\${HOST}", "cmd": "$(whoami)"}`,
+			want:     `{"user": "gopher", "path": "C:\\example.com", "cmd": "$(whoami)"}`,
+		},
+		{
+			name:     "defaults",
+			template: "${EMPTY:-fallback} ${EMPTY-kept} ${MISSING:-${HOST}} ${PORT:+set}",
+			want:     "fallback  example.com set",
+		},
+		{
+			name:     "escaped",
+			template: "$${HOST} costs $$5",
+			want:     "${HOST} costs $5",
+		},
+		{
+			name:     "undefined",
+			template: "[${MISSING}]",
+			want:     "[]",
+		},
+		{
+			name:     "nounset",
+			template: "line one\n  value: ${MISSING}\n",
+			options:  []dotenv.Option{dotenv.NoUnset()},
+			err:      `template:2:10: undefined variable "MISSING"`,
+		},
+		{
+			name:     "nounset default",
+			template: "${MISSING:-default}",
+			options:  []dotenv.Option{dotenv.NoUnset()},
+			want:     "default",
+		},
+		{
+			name:     "required",
+			template: "a\n${MISSING:?must be set}",
+			err:      "template:2:1: MISSING: must be set",
+		},
+		{
+			name:     "unterminated",
+			template: "ok ${HOST",
+			err:      "template:1:4: unterminated variable expansion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]dotenv.Option{dotenv.In(environment)}, tt.options...)
+
+			got, err := dotenv.ExpandTemplate(strings.NewReader(tt.template), env, options...)
+			if tt.err != "" {
+				test.Err(t, err)
+				test.Equal(t, err.Error(), tt.err)
+
+				return
+			}
+
+			test.Ok(t, err)
+			test.Equal(t, string(got), tt.want)
+		})
+	}
+}
+
+func TestExpandTemplateFileName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	test.Ok(t, os.WriteFile(path, []byte("listen ${PORT};\n"), 0o600))
+
+	file, err := os.Open(path)
+	test.Ok(t, err)
+
+	defer file.Close()
+
+	_, err = dotenv.ExpandTemplate(file, dotenv.Env{}, dotenv.In(dotenv.Memory(nil)), dotenv.NoUnset())
+	test.Err(t, err)
+	test.Equal(t, err.Error(), path+`:1:8: undefined variable "PORT"`)
+}
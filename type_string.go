@@ -0,0 +1,30 @@
+// Code generated by "stringer -type Type -linecomment"; DO NOT EDIT.
+
+package dotenv
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[TypeString-0]
+	_ = x[TypeInt-1]
+	_ = x[TypeBool-2]
+	_ = x[TypeURL-3]
+	_ = x[TypeDuration-4]
+	_ = x[TypeEnum-5]
+	_ = x[TypeRegex-6]
+}
+
+const _Type_name = "stringintboolurldurationenumregex"
+
+var _Type_index = [...]uint8{0, 6, 9, 13, 16, 24, 28, 33}
+
+func (i Type) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_Type_index)-1 {
+		return "Type(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Type_name[_Type_index[idx]:_Type_index[idx+1]]
+}
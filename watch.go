@@ -0,0 +1,348 @@
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPollInterval is how often files are checked for changes when polling,
+	// unless set by [Poll].
+	defaultPollInterval = time.Second
+
+	// settle is how long to wait after a change is noticed before reloading, editors
+	// often save a file in several steps and the reload should see the end result.
+	settle = 50 * time.Millisecond
+
+	// eventBuffer is the number of events buffered before the watcher waits for a reader.
+	eventBuffer = 16
+)
+
+// Event describes a change to the variables loaded by a [Watcher].
+type Event struct {
+	Err     error    // Why the files could not be reloaded, if they couldn't, Env is then unchanged
+	Env     Env      // The variables after the change
+	Added   []string // Variables that are new
+	Removed []string // Variables that no longer exist
+	Changed []string // Variables whose value changed
+}
+
+// Watcher watches a set of .env files, reloading them whenever they change.
+//
+// On Linux changes are noticed immediately using inotify, elsewhere (or if inotify
+// is unavailable) the files are polled.
+//
+// A Watcher is safe for concurrent use.
+type Watcher struct {
+	notifier notifier      // Reports when the files may have changed
+	events   chan Event    // Where events are delivered
+	done     chan struct{} // Closed by Close to stop the watcher
+	stopped  chan struct{} // Closed once the watch loop has exited
+	env      Env           // The last good state, guarded by mu
+	files    []string      // The files being watched, in order
+	cfg      config        // Configuration for loading the files
+	mu       sync.RWMutex  // Guards env
+	once     sync.Once     // Makes Close idempotent
+}
+
+// Watch loads the .env files given by the [File] option (or [DefaultFile] if none
+// were given) and watches them for changes.
+//
+// Unlike [Load], the process environment is not modified, read the variables with
+// [Watcher.Env] and receive changes from [Watcher.Events]. Otherwise the files are
+// loaded as [Load] would, so later files can refer to variables from earlier ones and
+// the first to define a variable wins unless the [Overwrite] option is given.
+//
+// If any of the files cannot be loaded to begin with, Watch returns the error; once
+// watching, errors are reported in an [Event] and the last good state is kept.
+//
+// The Watcher must be closed with [Watcher.Close] when no longer needed.
+func Watch(options ...Option) (*Watcher, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := cfg.files
+	if len(files) == 0 {
+		files = []string{DefaultFile}
+	}
+
+	w := &Watcher{
+		files:   files,
+		cfg:     cfg,
+		events:  make(chan Event, eventBuffer),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	env, contents, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	w.env = env
+
+	if cfg.poll == 0 {
+		w.notifier, err = newNotifier(files)
+	}
+
+	if cfg.poll != 0 || err != nil {
+		interval := cfg.poll
+		if interval == 0 {
+			interval = defaultPollInterval
+		}
+
+		w.notifier = newPoller(files, contents, interval)
+	}
+
+	go w.watch()
+
+	return w, nil
+}
+
+// Env returns the variables as of the last successful load.
+func (w *Watcher) Env() Env {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.env
+}
+
+// Events returns the channel on which changes are delivered.
+//
+// An event is sent whenever a reload changes the variables or fails. The channel
+// is closed by [Watcher.Close]. Events are buffered, but if they aren't received the
+// watcher eventually waits, so keep reading until the Watcher is closed.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Close stops watching the files and closes the events channel.
+//
+// It is safe to call Close more than once.
+func (w *Watcher) Close() error {
+	var err error
+
+	w.once.Do(func() {
+		close(w.done)
+		err = w.notifier.close()
+		<-w.stopped
+	})
+
+	return err
+}
+
+// watch reloads the files each time the notifier reports a change, until closed.
+func (w *Watcher) watch() {
+	defer close(w.stopped)
+	defer close(w.events)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.notifier.changes():
+		}
+
+		// Let the change settle, then drain any notifications it caused
+		select {
+		case <-w.done:
+			return
+		case <-time.After(settle):
+		}
+
+		select {
+		case <-w.notifier.changes():
+		default:
+		}
+
+		event, ok := w.reload()
+		if !ok {
+			continue
+		}
+
+		select {
+		case <-w.done:
+			return
+		case w.events <- event:
+		}
+	}
+}
+
+// reload loads the files again, updating the state and returning the event
+// describing the change, or false if nothing changed.
+func (w *Watcher) reload() (Event, bool) {
+	env, _, err := w.load()
+	if err != nil {
+		return Event{Env: w.Env(), Err: err}, true
+	}
+
+	w.mu.Lock()
+	previous := w.env
+	w.env = env
+	w.mu.Unlock()
+
+	event := Event{Env: env}
+	for _, difference := range Compare(previous, env) {
+		switch difference.Kind {
+		case LeftOnly:
+			event.Removed = append(event.Removed, difference.Key)
+		case RightOnly:
+			event.Added = append(event.Added, difference.Key)
+		case ValueDiffers:
+			event.Changed = append(event.Changed, difference.Key)
+		default:
+			// Order doesn't matter to anyone reading the variables
+		}
+	}
+
+	changed := len(event.Added) != 0 || len(event.Removed) != 0 || len(event.Changed) != 0
+
+	return event, changed
+}
+
+// load loads all the files in order with the same precedence as [Load], returning the
+// result and the contents of each file.
+//
+// Each file sees the variables from the ones before it, and the first file to define a
+// variable wins unless the [Overwrite] option was given.
+func (w *Watcher) load() (Env, [][]byte, error) {
+	var env Env
+
+	// Like the process environment after Load, but kept to ourselves
+	cfg := w.cfg
+	cfg.environment = Layered(Memory(nil), w.cfg.environment)
+
+	contents := make([][]byte, 0, len(w.files))
+	for _, file := range w.files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return Env{}, nil, err
+		}
+
+		loaded, err := resolve(file, src, cfg)
+		if err != nil {
+			return Env{}, nil, err
+		}
+
+		for key, value := range loaded.All() {
+			if _, exists := env.Get(key); exists && !cfg.overwrite {
+				continue
+			}
+
+			if err := cfg.environment.Set(key, value); err != nil {
+				return Env{}, nil, fmt.Errorf("could not set %s: %w", key, err)
+			}
+
+			merge(&env, loaded, key)
+		}
+
+		contents = append(contents, src)
+	}
+
+	return env, contents, nil
+}
+
+// merge sets the variable key from src in dst, along with its documentation, position
+// and whether it's secret.
+func merge(dst *Env, src Env, key string) {
+	value, _ := src.Get(key)
+	set(dst, key, value)
+
+	if comment := src.Comment(key); comment != "" {
+		document(dst, key, comment)
+	}
+
+	if pos, ok := src.positions[key]; ok {
+		locate(dst, key, pos)
+	}
+
+	if src.IsSecret(key) {
+		conceal(dst, key)
+	}
+}
+
+// notifier reports when the watched files may have changed.
+type notifier interface {
+	// changes returns a channel that receives a value when the files may have changed.
+	changes() <-chan struct{}
+
+	// close stops the notifier.
+	close() error
+}
+
+// poller is a [notifier] that reads the files at an interval to see if they've changed.
+type poller struct {
+	changed  chan struct{} // Signalled when a file has changed
+	done     chan struct{} // Closed to stop polling
+	files    []string      // The files to poll
+	contents [][]byte      // The last seen contents of each file, nil if it couldn't be read
+	interval time.Duration // How often to poll
+}
+
+// newPoller returns a [poller] watching files, whose current contents are given,
+// and starts it polling.
+func newPoller(files []string, contents [][]byte, interval time.Duration) *poller {
+	p := &poller{
+		files:    files,
+		contents: contents,
+		interval: interval,
+		changed:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	go p.poll()
+
+	return p
+}
+
+// poll checks the files every interval until closed.
+func (p *poller) poll() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		for i, file := range p.files {
+			contents, err := os.ReadFile(file)
+			if err != nil {
+				contents = nil
+			}
+
+			if bytes.Equal(contents, p.contents[i]) && (contents == nil) == (p.contents[i] == nil) {
+				continue
+			}
+
+			p.contents[i] = contents
+			signal(p.changed)
+		}
+	}
+}
+
+// changes implements [notifier] for a [poller].
+func (p *poller) changes() <-chan struct{} {
+	return p.changed
+}
+
+// close implements [notifier] for a [poller].
+func (p *poller) close() error {
+	close(p.done)
+	return nil
+}
+
+// signal sends on ch without blocking, a pending signal is as good as another.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,105 @@
+//go:build linux
+
+package dotenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask is the set of inotify events that may mean a watched file has changed,
+// including editors saving by renaming a new file over the old one.
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// inotifyEvents is the number of events to make room for in each read.
+const inotifyEvents = 64
+
+// inotify is a [notifier] using Linux's inotify.
+//
+// The directories containing the files are watched rather than the files themselves,
+// so that a file being replaced or removed and created again is noticed.
+type inotify struct {
+	file    *os.File                  // The inotify instance, read through the runtime poller so close interrupts reads
+	changed chan struct{}             // Signalled when a file has changed
+	names   map[int32]map[string]bool // Names of the watched files, by the descriptor of the watch on their directory
+}
+
+// newNotifier returns an inotify [notifier] watching files.
+func newNotifier(files []string) (notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialise inotify: %w", err)
+	}
+
+	n := &inotify{
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		changed: make(chan struct{}, 1),
+		names:   make(map[int32]map[string]bool),
+	}
+
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, errors.Join(err, n.file.Close())
+		}
+
+		wd, err := unix.InotifyAddWatch(fd, filepath.Dir(abs), inotifyMask)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("could not watch %s: %w", filepath.Dir(abs), err), n.file.Close())
+		}
+
+		if n.names[int32(wd)] == nil { //nolint:gosec // Watch descriptors are small, it's an int32 in the event
+			n.names[int32(wd)] = make(map[string]bool) //nolint:gosec // As above
+		}
+
+		n.names[int32(wd)][filepath.Base(abs)] = true //nolint:gosec // As above
+	}
+
+	go n.read()
+
+	return n, nil
+}
+
+// read reads events from inotify until it's closed, signalling any that concern
+// the watched files.
+func (n *inotify) read() {
+	buf := make([]byte, unix.SizeofInotifyEvent*inotifyEvents+unix.PathMax)
+
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			// Either closed, or broken in which case there's nothing sensible to do
+			// and the watcher just stops noticing changes
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= count; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + unix.SizeofInotifyEvent
+			end := start + int(event.Len)
+
+			name := bytes.TrimRight(buf[start:end], "\x00") // Names are padded with nulls
+			if n.names[event.Wd][string(name)] {
+				signal(n.changed)
+			}
+
+			offset = end
+		}
+	}
+}
+
+// changes implements [notifier] for [inotify].
+func (n *inotify) changes() <-chan struct{} {
+	return n.changed
+}
+
+// close implements [notifier] for [inotify].
+func (n *inotify) close() error {
+	return n.file.Close()
+}
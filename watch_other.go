@@ -0,0 +1,11 @@
+//go:build !linux
+
+package dotenv
+
+import "errors"
+
+// newNotifier returns an error as there are no native file notifications on this
+// platform, so a [Watcher] polls instead.
+func newNotifier([]string) (notifier, error) {
+	return nil, errors.New("file notifications are not supported on this platform")
+}
@@ -0,0 +1,140 @@
+package dotenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.followtheprocess.codes/dotenv"
+	"go.followtheprocess.codes/test"
+)
+
+// waitTimeout is how long to wait for a watcher event before failing.
+const waitTimeout = 5 * time.Second
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name    string          // Name of the test case
+		options []dotenv.Option // Extra options to pass to Watch
+	}{
+		{name: "notify"},
+		{name: "poll", options: []dotenv.Option{dotenv.Poll(10 * time.Millisecond)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			base := filepath.Join(dir, ".env")
+			local := filepath.Join(dir, ".env.local")
+
+			write(t, base, "A=1\nB=2\nC=3\nG=${L}!\n")
+			write(t, local, "C=local\nL=local\n")
+
+			w, err := dotenv.Watch(slices.Concat([]dotenv.Option{dotenv.File(local), dotenv.File(base)}, tt.options)...)
+			test.Ok(t, err)
+
+			defer w.Close()
+
+			c, _ := w.Env().Get("C")
+			test.Equal(t, c, "local", test.Context("earlier files take precedence, as with Load"))
+
+			g, _ := w.Env().Get("G")
+			test.Equal(t, g, "local!", test.Context("later files can refer to earlier ones"))
+
+			write(t, base, "A=changed\nC=3\nD=4\nG=${L}!\n")
+
+			event := next(t, w)
+			test.Ok(t, event.Err)
+			test.EqualFunc(t, event.Added, []string{"D"}, slices.Equal)
+			test.EqualFunc(t, event.Removed, []string{"B"}, slices.Equal)
+			test.EqualFunc(t, event.Changed, []string{"A"}, slices.Equal)
+
+			a, _ := w.Env().Get("A")
+			test.Equal(t, a, "changed")
+
+			// A syntax error keeps the last good state
+			write(t, local, "C=\"unterminated\n")
+
+			event = next(t, w)
+			test.Err(t, event.Err)
+
+			a, _ = w.Env().Get("A")
+			test.Equal(t, a, "changed")
+			a, _ = event.Env.Get("A")
+			test.Equal(t, a, "changed")
+
+			// Replacing the file, as many editors do, is noticed too
+			tmp := filepath.Join(dir, "tmp")
+			write(t, tmp, "C=fixed\nL=local\n")
+			test.Ok(t, os.Rename(tmp, local))
+
+			event = next(t, w)
+			test.Ok(t, event.Err)
+			test.EqualFunc(t, event.Changed, []string{"C"}, slices.Equal)
+
+			test.Ok(t, w.Close())
+			test.Ok(t, w.Close(), test.Context("closing twice is fine"))
+
+			_, open := <-w.Events()
+			test.False(t, open, test.Context("events should be closed"))
+		})
+	}
+}
+
+func TestWatchConcurrentReaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	write(t, path, "A=0\n")
+
+	w, err := dotenv.Watch(dotenv.File(path), dotenv.Poll(time.Millisecond))
+	test.Ok(t, err)
+
+	defer w.Close()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 1000 {
+				_, _ = w.Env().Get("A")
+			}
+		}()
+	}
+
+	write(t, path, "A=1\n")
+	next(t, w)
+	wg.Wait()
+}
+
+func TestWatchErrors(t *testing.T) {
+	_, err := dotenv.Watch(dotenv.File(filepath.Join(t.TempDir(), "missing.env")))
+	test.Err(t, err)
+
+	_, err = dotenv.Watch(dotenv.Poll(0))
+	test.Err(t, err)
+	test.Equal(t, err.Error(), "poll interval must be positive")
+}
+
+// write writes contents to the file at path, failing the test if it can't.
+func write(t *testing.T, path, contents string) {
+	t.Helper()
+	test.Ok(t, os.WriteFile(path, []byte(contents), 0o600))
+}
+
+// next waits for the next event from w, failing the test if there isn't one in time.
+func next(t *testing.T, w *dotenv.Watcher) dotenv.Event {
+	t.Helper()
+
+	select {
+	case event := <-w.Events():
+		return event
+	case <-time.After(waitTimeout):
+		t.Fatal("timed out waiting for an event")
+		return dotenv.Event{}
+	}
+}